@@ -0,0 +1,60 @@
+package access
+
+import (
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+)
+
+// AdminAttribute is the certificate attribute (issued by the Fabric CA) that grants administrative rights
+const AdminAttribute = "admin"
+
+// IsAdmin returns true when the client certificate carries admin=true
+func IsAdmin(identity cid.ClientIdentity) (bool, error) {
+	value, found, err := identity.GetAttributeValue(AdminAttribute)
+	if err != nil {
+		return false, errs.Internalf("failed to read client identity: %v", err)
+	}
+	return found && value == "true", nil
+}
+
+// AssertAdmin returns an error unless the client certificate carries admin=true
+func AssertAdmin(identity cid.ClientIdentity) error {
+	admin, err := IsAdmin(identity)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return errs.Unauthorizedf("the client is not allowed to perform this operation, attribute %s=true is required", AdminAttribute)
+	}
+	return nil
+}
+
+// MSPID returns the MSP ID of the organization of the client
+func MSPID(identity cid.ClientIdentity) (string, error) {
+	mspID, err := identity.GetMSPID()
+	if err != nil {
+		return "", errs.Internalf("failed to read client identity: %v", err)
+	}
+	return mspID, nil
+}
+
+// HostnameAttribute is the certificate attribute binding a collector identity to the host it measures
+const HostnameAttribute = "hostname"
+
+// AssertHostname returns an error when the client certificate is bound to another host,
+// clients without the attribute can post for any host
+func AssertHostname(identity cid.ClientIdentity, hostname string) error {
+	value, found, err := identity.GetAttributeValue(HostnameAttribute)
+	if err != nil {
+		return errs.Internalf("failed to read client identity: %v", err)
+	}
+	if found && value != hostname {
+		return errs.Unauthorizedf("the client is bound to the host %s (attribute %s) and can not post for %s", value, HostnameAttribute, hostname)
+	}
+	return nil
+}
+
+// OrgCollection returns the implicit private data collection of the organization, only its peers store the data
+func OrgCollection(mspID string) string {
+	return "_implicit_org_" + mspID
+}
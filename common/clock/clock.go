@@ -0,0 +1,72 @@
+package clock
+
+import (
+	"time"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Clock provides "now" to the Smart Contracts. Time windows must not be computed with time.Now(),
+// otherwise every endorsing peer computes a different window and endorsement fails
+type Clock interface {
+	Now() (time.Time, error)
+}
+
+// TxClock derives "now" from the timestamp of the transaction proposal, which is the same for every endorsing peer
+type TxClock struct {
+	stub shim.ChaincodeStubInterface
+}
+
+func New(stub shim.ChaincodeStubInterface) TxClock {
+	return TxClock{stub: stub}
+}
+
+func (c TxClock) Now() (time.Time, error) {
+	txTime, err := c.stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, errs.Internalf("failed to read transaction timestamp: %v", err)
+	}
+	return time.Unix(txTime.Seconds, int64(txTime.Nanos)).UTC(), nil
+}
+
+// FixedClock always returns the same time, used to evaluate historic windows
+type FixedClock struct {
+	t time.Time
+}
+
+func Fixed(t time.Time) FixedClock {
+	return FixedClock{t: t}
+}
+
+func (c FixedClock) Now() (time.Time, error) {
+	return c.t, nil
+}
+
+// Window returns the time window covering the last minutes as unix seconds, from inclusive and to exclusive
+func Window(c Clock, minutes int) (fromSeconds int64, toSeconds int64, err error) {
+	if minutes < 0 {
+		return 0, 0, errs.InvalidArgumentf("the time window can not be negative: %d minutes", minutes)
+	}
+	now, err := c.Now()
+	if err != nil {
+		return 0, 0, err
+	}
+	return now.Add(-time.Duration(minutes) * time.Minute).Unix(), now.Unix(), nil
+}
+
+// ValidateWindow checks an explicit (fromSeconds, toSeconds) window
+func ValidateWindow(fromSeconds int64, toSeconds int64) error {
+	if fromSeconds < 0 || toSeconds < 0 {
+		return errs.InvalidArgumentf("the time window can not be negative: %d - %d", fromSeconds, toSeconds)
+	}
+	if fromSeconds > toSeconds {
+		return errs.InvalidArgumentf("the time window starts after it ends: %d - %d", fromSeconds, toSeconds)
+	}
+	return nil
+}
+
+// WindowMinutes returns the duration of a window in minutes
+func WindowMinutes(fromSeconds int64, toSeconds int64) int {
+	return int((toSeconds - fromSeconds) / 60)
+}
@@ -0,0 +1,89 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+var now = time.Unix(1700000000, 500).UTC()
+
+type brokenClock struct{}
+
+func (brokenClock) Now() (time.Time, error) {
+	return time.Time{}, errs.Internalf("no clock")
+}
+
+func TestTxClock(t *testing.T) {
+	stub := shimtest.NewMockStub("clock", nil)
+	stub.TxTimestamp = &timestamp.Timestamp{Seconds: now.Unix(), Nanos: int32(now.Nanosecond())}
+	got, err := New(stub).Now()
+	if err != nil {
+		t.Fatalf("Now: %v", err)
+	}
+	if !got.Equal(now) || got.Location() != time.UTC {
+		t.Errorf("Now = %v, want %v", got, now)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		clock    Clock
+		minutes  int
+		wantFrom int64
+		wantTo   int64
+		code     errs.Code
+	}{
+		{"empty window", Fixed(now), 0, now.Unix(), now.Unix(), ""},
+		{"one minute", Fixed(now), 1, now.Unix() - 60, now.Unix(), ""},
+		{"one day", Fixed(now), 24 * 60, now.Unix() - 86400, now.Unix(), ""},
+		{"negative", Fixed(now), -1, 0, 0, errs.InvalidArgument},
+		{"broken clock", brokenClock{}, 5, 0, 0, errs.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := Window(tt.clock, tt.minutes)
+			if tt.code == "" && err != nil {
+				t.Fatalf("Window: %v", err)
+			}
+			if tt.code != "" && (err == nil || errs.CodeOf(err) != tt.code) {
+				t.Fatalf("Window = %v, want %s", err, tt.code)
+			}
+			if from != tt.wantFrom || to != tt.wantTo {
+				t.Errorf("Window = %d - %d, want %d - %d", from, to, tt.wantFrom, tt.wantTo)
+			}
+			if err == nil && WindowMinutes(from, to) != tt.minutes {
+				t.Errorf("WindowMinutes = %d, want %d", WindowMinutes(from, to), tt.minutes)
+			}
+		})
+	}
+}
+
+func TestValidateWindow(t *testing.T) {
+	tests := []struct {
+		from  int64
+		to    int64
+		valid bool
+	}{
+		{0, 0, true},
+		{0, 60, true},
+		{60, 60, true},
+		{60, 59, false},
+		{-1, 60, false},
+		{0, -1, false},
+	}
+	for _, tt := range tests {
+		err := ValidateWindow(tt.from, tt.to)
+		if tt.valid && err != nil {
+			t.Errorf("ValidateWindow(%d, %d) = %v", tt.from, tt.to, err)
+		}
+		if !tt.valid && (err == nil || errs.CodeOf(err) != errs.InvalidArgument) {
+			t.Errorf("ValidateWindow(%d, %d) = %v, want INVALID_ARGUMENT", tt.from, tt.to, err)
+		}
+	}
+}
+
@@ -0,0 +1,97 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Code identifies the class of an error, clients branch on the code instead of the message
+type Code string
+
+const (
+	NotFound        Code = "NOT_FOUND"
+	AlreadyExists   Code = "ALREADY_EXISTS"
+	InvalidArgument Code = "INVALID_ARGUMENT"
+	Unauthorized    Code = "UNAUTHORIZED"
+	Internal        Code = "INTERNAL" //world state or cross Smart Contract failures
+)
+
+var codes = []Code{NotFound, AlreadyExists, InvalidArgument, Unauthorized, Internal}
+
+// Error is returned by every transaction. The message received by the client is "CODE: message"
+type Error struct {
+	Code    Code
+	Message string
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+func (e *Error) ErrorCode() Code {
+	return e.Code
+}
+
+// Coder is implemented by the errors that carry a Code
+type Coder interface {
+	ErrorCode() Code
+}
+
+func New(code Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func NotFoundf(format string, args ...interface{}) error {
+	return New(NotFound, format, args...)
+}
+
+func AlreadyExistsf(format string, args ...interface{}) error {
+	return New(AlreadyExists, format, args...)
+}
+
+func InvalidArgumentf(format string, args ...interface{}) error {
+	return New(InvalidArgument, format, args...)
+}
+
+func Unauthorizedf(format string, args ...interface{}) error {
+	return New(Unauthorized, format, args...)
+}
+
+func Internalf(format string, args ...interface{}) error {
+	return New(Internal, format, args...)
+}
+
+// Wrap returns err with the given code, unless it already carries one
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	var coder Coder
+	if errors.As(err, &coder) {
+		return err
+	}
+	return &Error{Code: code, Message: err.Error()}
+}
+
+// CodeOf returns the code of err, errors without a code are Internal
+func CodeOf(err error) Code {
+	var coder Coder
+	if errors.As(err, &coder) {
+		return coder.ErrorCode()
+	}
+	code, _ := Parse(err.Error())
+	return code
+}
+
+// Parse splits a "CODE: message" string, as received from another Smart Contract.
+// Messages without a known code are Internal
+func Parse(message string) (Code, string) {
+	for _, code := range codes {
+		prefix := string(code) + ": "
+		if strings.HasPrefix(message, prefix) {
+			return code, strings.TrimPrefix(message, prefix)
+		}
+	}
+	return Internal, message
+}
@@ -0,0 +1,112 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Geohash encoding and distances used to index and search assets by position
+
+const (
+	base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+	// Precision of the stored geohashes, cells of about 4.8 x 4.8 meters
+	MaxPrecision = 9
+
+	// Largest radius that can be searched, the 3x3 cells around the point must cover the circle
+	MaxRadiusMeters = 1000000
+
+	earthRadiusMeters = 6371000
+	metersPerDegree   = 111320
+)
+
+func ValidateCoordinates(lat float64, lon float64) error {
+	if math.IsNaN(lat) || math.IsNaN(lon) || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return fmt.Errorf("invalid coordinates (%v, %v), latitude must be in [-90, 90] and longitude in [-180, 180]", lat, lon)
+	}
+	return nil
+}
+
+// Encode returns the geohash of the point with the given number of characters
+func Encode(lat float64, lon float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+	var hash strings.Builder
+	bit, ch, even := 0, 0, true
+	for hash.Len() < precision {
+		if even {
+			ch = ch<<1 | refine(&lonRange, lon)
+		} else {
+			ch = ch<<1 | refine(&latRange, lat)
+		}
+		even = !even
+		bit++
+		if bit == 5 {
+			hash.WriteByte(base32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return hash.String()
+}
+
+func refine(r *[2]float64, value float64) int {
+	mid := (r[0] + r[1]) / 2
+	if value >= mid {
+		r[0] = mid
+		return 1
+	}
+	r[1] = mid
+	return 0
+}
+
+// CellSize returns the height and width (degrees) of the cells of the given precision
+func CellSize(precision int) (float64, float64) {
+	bits := 5 * precision
+	latBits := bits / 2
+	lonBits := bits - latBits
+	return 180 / math.Pow(2, float64(latBits)), 360 / math.Pow(2, float64(lonBits))
+}
+
+// Cover returns the geohash prefixes whose cells contain every point within radius of (lat, lon):
+// the cell of the point and its neighbours, at the finest precision where a cell is larger than the radius
+func Cover(lat float64, lon float64, radiusMeters float64) []string {
+	precision := 1
+	for p := MaxPrecision; p >= 1; p-- {
+		height, width := CellSize(p)
+		if height*metersPerDegree >= radiusMeters && width*metersPerDegree*math.Cos(lat*math.Pi/180) >= radiusMeters {
+			precision = p
+			break
+		}
+	}
+
+	height, width := CellSize(precision)
+	seen := make(map[string]bool)
+	cells := []string{}
+	for _, dLat := range []float64{-height, 0, height} {
+		for _, dLon := range []float64{-width, 0, width} {
+			cellLat := math.Max(-90, math.Min(90, lat+dLat))
+			cellLon := lon + dLon
+			if cellLon < -180 {
+				cellLon += 360
+			} else if cellLon >= 180 {
+				cellLon -= 360
+			}
+			cell := Encode(cellLat, cellLon, precision)
+			if !seen[cell] {
+				seen[cell] = true
+				cells = append(cells, cell)
+			}
+		}
+	}
+	return cells
+}
+
+// Distance returns the haversine distance in meters between two points
+func Distance(lat1 float64, lon1 float64, lat2 float64, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
@@ -0,0 +1,136 @@
+package geo
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		lat, lon  float64
+		precision int
+		want      string
+	}{
+		{57.64911, 10.40744, 9, "u4pruydqq"},
+		{57.64911, 10.40744, 5, "u4pru"},
+		{0, 0, 1, "s"},
+		{-90, -180, 3, "000"},
+		{90, 180, 3, "zzz"},
+	}
+	for _, tt := range tests {
+		if got := Encode(tt.lat, tt.lon, tt.precision); got != tt.want {
+			t.Errorf("Encode(%v, %v, %d) = %s, want %s", tt.lat, tt.lon, tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{60.449, 22.295, 60.449, 22.295, 0},
+		{0, 0, 1, 0, 111195},
+		{0, 179.5, 0, -179.5, 111195},
+		{90, 0, -90, 0, math.Pi * earthRadiusMeters},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.lat1, tt.lon1, tt.lat2, tt.lon2); math.Abs(got-tt.want) > 1 {
+			t.Errorf("Distance(%v, %v, %v, %v) = %v, want %v", tt.lat1, tt.lon1, tt.lat2, tt.lon2, got, tt.want)
+		}
+	}
+}
+
+func TestValidateCoordinates(t *testing.T) {
+	valid := [][2]float64{{0, 0}, {90, 180}, {-90, -180}, {60.449, 22.295}}
+	for _, c := range valid {
+		if err := ValidateCoordinates(c[0], c[1]); err != nil {
+			t.Errorf("ValidateCoordinates(%v, %v): %v", c[0], c[1], err)
+		}
+	}
+	invalid := [][2]float64{{90.1, 0}, {-90.1, 0}, {0, 180.1}, {0, -180.1}, {math.NaN(), 0}, {0, math.NaN()}}
+	for _, c := range invalid {
+		if err := ValidateCoordinates(c[0], c[1]); err == nil {
+			t.Errorf("ValidateCoordinates(%v, %v) accepted invalid coordinates", c[0], c[1])
+		}
+	}
+}
+
+// destination returns the point at distance meters from (lat, lon) in the given bearing (radians)
+func destination(lat float64, lon float64, bearing float64, meters float64) (float64, float64) {
+	toRad := math.Pi / 180
+	angular := meters / earthRadiusMeters
+	lat1, lon1 := lat*toRad, lon*toRad
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angular) + math.Cos(lat1)*math.Sin(angular)*math.Cos(bearing))
+	lon2 := lon1 + math.Atan2(math.Sin(bearing)*math.Sin(angular)*math.Cos(lat1), math.Cos(angular)-math.Sin(lat1)*math.Sin(lat2))
+	lon2 = math.Mod(lon2/toRad+540, 360) - 180
+	return lat2 / toRad, lon2
+}
+
+func TestCoverContainsEveryPointInRadius(t *testing.T) {
+	centers := []struct {
+		name     string
+		lat, lon float64
+	}{
+		{"turku", 60.449, 22.295},
+		{"equator", 0, 0},
+		{"cell corner", 45, 45},
+		{"southern hemisphere", -33.8688, 151.2093},
+		{"antimeridian west", 10, 179.9999},
+		{"antimeridian east", -10, -179.9999},
+		{"high latitude", 78.2232, 15.6267},
+	}
+	radii := []float64{5, 50, 500, 5000, 50000, 500000}
+	random := rand.New(rand.NewSource(1))
+
+	for _, center := range centers {
+		for _, radius := range radii {
+			cells := Cover(center.lat, center.lon, radius)
+			if len(cells) == 0 || len(cells) > 9 {
+				t.Fatalf("%s, %vm: Cover returned %d cells, want 1 to 9", center.name, radius, len(cells))
+			}
+			for i := 0; i < 200; i++ {
+				bearing := random.Float64() * 2 * math.Pi
+				// THE FIRST POINTS ARE ON THE CIRCLE, THE OTHERS INSIDE IT
+				meters := radius
+				if i >= 50 {
+					meters = random.Float64() * radius
+				}
+				lat, lon := destination(center.lat, center.lon, bearing, meters*0.999)
+				hash := Encode(lat, lon, MaxPrecision)
+				if !coveredBy(hash, cells) {
+					t.Errorf("%s, %vm: (%v, %v) at %.1fm with geohash %s is not covered by %v",
+						center.name, radius, lat, lon, Distance(center.lat, center.lon, lat, lon), hash, cells)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestCoverPrecision(t *testing.T) {
+	// CELLS SHRINK WITH THE RADIUS, THE SMALLEST RADIUS USES THE STORED PRECISION
+	previous := 0
+	for _, radius := range []float64{MaxRadiusMeters, 100000, 1000, 1} {
+		cells := Cover(60.449, 22.295, radius)
+		precision := len(cells[0])
+		if precision < previous {
+			t.Errorf("the precision for %vm is %d, lower than %d for a larger radius", radius, precision, previous)
+		}
+		previous = precision
+	}
+	if previous != MaxPrecision {
+		t.Errorf("the precision for 1m is %d, want %d", previous, MaxPrecision)
+	}
+}
+
+func coveredBy(hash string, cells []string) bool {
+	for _, cell := range cells {
+		if strings.HasPrefix(hash, cell) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package invoke
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// -- CONFIGURATION
+// Contract-level record with the names of the Smart Contracts invoked by a chaincode and their channel
+type Config struct {
+	Channel   string `json:"channel"` //"" = same channel as the invoking chaincode
+	Inventory string `json:"inventory"`
+	Latency   string `json:"latency"`
+	Resources string `json:"resources"`
+}
+
+const ConfigObjectType = "config"
+
+var DefaultConfig = Config{
+	Channel:   "",
+	Inventory: "inventory-sc",
+	Latency:   "latency-sc",
+	Resources: "resources-sc",
+}
+
+func (d Config) String() string {
+	s, _ := json.Marshal(d)
+	return string(s)
+}
+
+func JsonToConfig(v string) (config Config, err error) {
+	err = json.Unmarshal([]byte(v), &config)
+	if err != nil {
+		return config, errs.InvalidArgumentf("invalid configuration: %v", err)
+	}
+	return config, nil
+}
+
+func (d Config) InventoryChaincode() Chaincode {
+	return Chaincode{Name: d.Inventory, Channel: d.Channel}
+}
+
+func (d Config) LatencyChaincode() Chaincode {
+	return Chaincode{Name: d.Latency, Channel: d.Channel}
+}
+
+func (d Config) ResourcesChaincode() Chaincode {
+	return Chaincode{Name: d.Resources, Channel: d.Channel}
+}
+
+// GetConfig returns the stored configuration, or DefaultConfig when none was set
+func GetConfig(stub shim.ChaincodeStubInterface) (Config, error) {
+	key, err := stub.CreateCompositeKey(ConfigObjectType, []string{})
+	if err != nil {
+		return Config{}, errs.Wrap(errs.Internal, err)
+	}
+	configJson, err := stub.GetState(key)
+	if err != nil {
+		return Config{}, errs.Internalf("failed to read from world state: %v", err)
+	}
+	if configJson == nil {
+		return DefaultConfig, nil
+	}
+	return JsonToConfig(string(configJson))
+}
+
+// PutConfig stores the configuration, empty chaincode names are replaced by the defaults
+func PutConfig(stub shim.ChaincodeStubInterface, config Config) (Config, error) {
+	if config.Inventory == "" {
+		config.Inventory = DefaultConfig.Inventory
+	}
+	if config.Latency == "" {
+		config.Latency = DefaultConfig.Latency
+	}
+	if config.Resources == "" {
+		config.Resources = DefaultConfig.Resources
+	}
+	key, err := stub.CreateCompositeKey(ConfigObjectType, []string{})
+	if err != nil {
+		return Config{}, errs.Wrap(errs.Internal, err)
+	}
+	return config, errs.Wrap(errs.Internal, stub.PutState(key, []byte(config.String())))
+}
+
+// -- INVOKER
+// Chaincode deployed on a channel that can be queried from another chaincode
+type Chaincode struct {
+	Name    string
+	Channel string
+}
+
+// Error returned when the invoked chaincode does not answer with shim.OK.
+// The code of the invoked chaincode error is kept, so a NOT_FOUND stays a NOT_FOUND
+type Error struct {
+	Chaincode string
+	Function  string
+	Status    int32
+	Code      errs.Code
+	Message   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: failed to query chaincode %s (%s). Error %s", e.Code, e.Chaincode, e.Function, e.Message)
+}
+
+func (e *Error) ErrorCode() errs.Code {
+	return e.Code
+}
+
+// Query invokes function on the chaincode and decodes the JSON payload into result (a pointer).
+// An empty payload leaves result untouched
+func (c Chaincode) Query(stub shim.ChaincodeStubInterface, result interface{}, function string, args ...string) error {
+	queryArgs := make([][]byte, 0, len(args)+1)
+	queryArgs = append(queryArgs, []byte(function))
+	for _, arg := range args {
+		queryArgs = append(queryArgs, []byte(arg))
+	}
+
+	response := stub.InvokeChaincode(c.Name, queryArgs, c.Channel)
+	if response.Status != shim.OK {
+		code, message := errs.Parse(response.Message)
+		return &Error{Chaincode: c.Name, Function: function, Status: response.Status, Code: code, Message: message}
+	}
+
+	payload := response.GetPayload()
+	if len(payload) == 0 || result == nil {
+		return nil
+	}
+	err := json.Unmarshal(payload, result)
+	if err != nil {
+		return errs.Internalf("failed to decode response of chaincode %s (%s). Error %v", c.Name, function, err)
+	}
+	return nil
+}
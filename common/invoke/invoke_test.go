@@ -0,0 +1,98 @@
+package invoke
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+)
+
+func TestGetConfigDefault(t *testing.T) {
+	config, err := GetConfig(shimtest.NewMockStub("invoke", nil))
+	if err != nil {
+		t.Fatalf("GetConfig: %v", err)
+	}
+	if config != DefaultConfig {
+		t.Errorf("GetConfig = %v, want %v", config, DefaultConfig)
+	}
+}
+
+func TestPutConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   Config
+	}{
+		{"defaults", Config{}, DefaultConfig},
+		{"renamed inventory", Config{Inventory: "inventory-v2"}, Config{Inventory: "inventory-v2", Latency: "latency-sc", Resources: "resources-sc"}},
+		{"other channel", Config{Channel: "infra", Latency: "lat"}, Config{Channel: "infra", Inventory: "inventory-sc", Latency: "lat", Resources: "resources-sc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := shimtest.NewMockStub("invoke", nil)
+			stub.MockTransactionStart("put")
+			stored, err := PutConfig(stub, tt.config)
+			stub.MockTransactionEnd("put")
+			if err != nil {
+				t.Fatalf("PutConfig: %v", err)
+			}
+			if stored != tt.want {
+				t.Errorf("PutConfig = %v, want %v", stored, tt.want)
+			}
+			config, err := GetConfig(stub)
+			if err != nil {
+				t.Fatalf("GetConfig: %v", err)
+			}
+			if config != tt.want {
+				t.Errorf("GetConfig = %v, want %v", config, tt.want)
+			}
+		})
+	}
+}
+
+func TestPutConfigOutsideTransaction(t *testing.T) {
+	// THE MOCK STUB REFUSES WRITES OUTSIDE A TRANSACTION
+	_, err := PutConfig(shimtest.NewMockStub("invoke", nil), Config{})
+	var coder errs.Coder
+	if !errors.As(err, &coder) || coder.ErrorCode() != errs.Internal {
+		t.Errorf("PutConfig = %v, want an INTERNAL error", err)
+	}
+}
+
+func TestGetConfigInvalid(t *testing.T) {
+	stub := shimtest.NewMockStub("invoke", nil)
+	key, _ := stub.CreateCompositeKey(ConfigObjectType, []string{})
+	stub.MockTransactionStart("put")
+	stub.PutState(key, []byte("{"))
+	stub.MockTransactionEnd("put")
+	_, err := GetConfig(stub)
+	if err == nil || errs.CodeOf(err) != errs.InvalidArgument {
+		t.Errorf("GetConfig = %v, want INVALID_ARGUMENT", err)
+	}
+}
+
+func TestConfigChaincodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"same channel", DefaultConfig},
+		{"other channel", Config{Channel: "infra", Inventory: "inv", Latency: "lat", Resources: "res"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chaincodes := map[string]Chaincode{
+				tt.config.Inventory: tt.config.InventoryChaincode(),
+				tt.config.Latency:   tt.config.LatencyChaincode(),
+				tt.config.Resources: tt.config.ResourcesChaincode(),
+			}
+			for name, chaincode := range chaincodes {
+				// AN EMPTY CHANNEL MAKES InvokeChaincode USE THE CHANNEL OF THE CALLER
+				if chaincode.Name != name || chaincode.Channel != tt.config.Channel {
+					t.Errorf("chaincode = %+v, want %s on channel %q", chaincode, name, tt.config.Channel)
+				}
+			}
+		})
+	}
+}
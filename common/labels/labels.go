@@ -0,0 +1,225 @@
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/mango"
+)
+
+// Kubernetes-style labels (zone=factory-2, tier=gpu) and label selectors:
+//   equality:  zone=factory-2, zone==factory-2, tier!=gpu
+//   set-based: zone in (factory-1,factory-2), tier notin (cpu)
+//   existence: gpu, !legacy
+// Requirements are separated by commas and must all match
+
+var (
+	namePattern   = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	prefixPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+	setPattern    = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+)
+
+type Operator string
+
+const (
+	Equals       Operator = "="
+	NotEquals    Operator = "!="
+	In           Operator = "in"
+	NotIn        Operator = "notin"
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+)
+
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+// Selector is a parsed label selector, the empty selector matches everything
+type Selector []Requirement
+
+// ValidateKey accepts [prefix/]name, the prefix being a DNS subdomain and the name up to 63 characters
+func ValidateKey(key string) error {
+	name := key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		prefix := key[:i]
+		name = key[i+1:]
+		if len(prefix) == 0 || len(prefix) > 253 || !prefixPattern.MatchString(prefix) {
+			return fmt.Errorf("invalid label key %q, the prefix must be a DNS subdomain", key)
+		}
+	}
+	if len(name) == 0 || len(name) > 63 || !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid label key %q, the name must be up to 63 alphanumeric characters, '-', '_' or '.'", key)
+	}
+	return nil
+}
+
+// ValidateValue accepts empty values or up to 63 alphanumeric characters, '-', '_' or '.'
+func ValidateValue(value string) error {
+	if value != "" && (len(value) > 63 || !namePattern.MatchString(value)) {
+		return fmt.Errorf("invalid label value %q, it must be up to 63 alphanumeric characters, '-', '_' or '.'", value)
+	}
+	return nil
+}
+
+func Validate(labels map[string]string) error {
+	for key, value := range labels {
+		if err := ValidateKey(key); err != nil {
+			return err
+		}
+		if err := ValidateValue(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse reads a label selector, e.g. "zone=factory-2,tier in (gpu,npu),!legacy"
+func Parse(selector string) (Selector, error) {
+	parsed := Selector{}
+	for _, part := range splitRequirements(selector) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		requirement, err := parseRequirement(part)
+		if err != nil {
+			return nil, err
+		}
+		if err := ValidateKey(requirement.Key); err != nil {
+			return nil, err
+		}
+		for _, value := range requirement.Values {
+			if err := ValidateValue(value); err != nil {
+				return nil, err
+			}
+		}
+		parsed = append(parsed, requirement)
+	}
+	return parsed, nil
+}
+
+func parseRequirement(part string) (Requirement, error) {
+	if strings.HasPrefix(part, "!") && !strings.ContainsAny(part, "=()") {
+		return Requirement{Key: strings.TrimSpace(part[1:]), Operator: DoesNotExist}, nil
+	}
+	if match := setPattern.FindStringSubmatch(part); match != nil {
+		values := []string{}
+		for _, value := range strings.Split(match[3], ",") {
+			values = append(values, strings.TrimSpace(value))
+		}
+		return Requirement{Key: match[1], Operator: Operator(match[2]), Values: values}, nil
+	}
+	for _, operator := range []string{"!=", "==", "="} {
+		if i := strings.Index(part, operator); i >= 0 {
+			key := strings.TrimSpace(part[:i])
+			value := strings.TrimSpace(part[i+len(operator):])
+			if operator == "!=" {
+				return Requirement{Key: key, Operator: NotEquals, Values: []string{value}}, nil
+			}
+			return Requirement{Key: key, Operator: Equals, Values: []string{value}}, nil
+		}
+	}
+	if strings.ContainsAny(part, " ()") {
+		return Requirement{}, fmt.Errorf("invalid label selector requirement %q", part)
+	}
+	return Requirement{Key: part, Operator: Exists}, nil
+}
+
+// splitRequirements splits on the commas that are not inside the value list of in/notin
+func splitRequirements(selector string) []string {
+	parts := []string{}
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, selector[start:])
+}
+
+// Matches returns true when the labels satisfy every requirement
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, requirement := range s {
+		value, found := labels[requirement.Key]
+		switch requirement.Operator {
+		case Equals:
+			if !found || value != requirement.Values[0] {
+				return false
+			}
+		case NotEquals:
+			if found && value == requirement.Values[0] {
+				return false
+			}
+		case In:
+			if !found || !contains(requirement.Values, value) {
+				return false
+			}
+		case NotIn:
+			if found && contains(requirement.Values, value) {
+				return false
+			}
+		case Exists:
+			if !found {
+				return false
+			}
+		case DoesNotExist:
+			if found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Conditions returns the Mango conditions matching the selector on the map stored in field (e.g. "labels").
+// As in Kubernetes, != and notin also match assets without the label
+func (s Selector) Conditions(field string) []mango.Condition {
+	conditions := []mango.Condition{}
+	for _, requirement := range s {
+		// DOTS IN LABEL KEYS ARE ESCAPED, OTHERWISE COUCHDB READS THEM AS NESTED FIELDS
+		path := field + "." + strings.ReplaceAll(requirement.Key, ".", `\.`)
+		switch requirement.Operator {
+		case Equals:
+			conditions = append(conditions, mango.Eq(path, requirement.Values[0]))
+		case NotEquals:
+			conditions = append(conditions, mango.Or(mango.Exists(path, false), mango.Ne(path, requirement.Values[0])))
+		case In:
+			conditions = append(conditions, mango.In(path, values(requirement.Values)...))
+		case NotIn:
+			conditions = append(conditions, mango.Or(mango.Exists(path, false), mango.Nin(path, values(requirement.Values)...)))
+		case Exists:
+			conditions = append(conditions, mango.Exists(path, true))
+		case DoesNotExist:
+			conditions = append(conditions, mango.Exists(path, false))
+		}
+	}
+	return conditions
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func values(v []string) []interface{} {
+	result := make([]interface{}, len(v))
+	for i, value := range v {
+		result[i] = value
+	}
+	return result
+}
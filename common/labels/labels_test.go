@@ -0,0 +1,107 @@
+package labels
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     Selector
+	}{
+		{"", Selector{}},
+		{" , ", Selector{}},
+		{"zone=factory-2", Selector{{Key: "zone", Operator: Equals, Values: []string{"factory-2"}}}},
+		{"zone == factory-2", Selector{{Key: "zone", Operator: Equals, Values: []string{"factory-2"}}}},
+		{"tier!=cpu", Selector{{Key: "tier", Operator: NotEquals, Values: []string{"cpu"}}}},
+		{"zone in (factory-1, factory-2)", Selector{{Key: "zone", Operator: In, Values: []string{"factory-1", "factory-2"}}}},
+		{"tier notin (cpu)", Selector{{Key: "tier", Operator: NotIn, Values: []string{"cpu"}}}},
+		{"gpu", Selector{{Key: "gpu", Operator: Exists}}},
+		{"!legacy", Selector{{Key: "legacy", Operator: DoesNotExist}}},
+		{"example.com/tier=gpu", Selector{{Key: "example.com/tier", Operator: Equals, Values: []string{"gpu"}}}},
+		{
+			"zone=factory-2,tier in (gpu,npu),!legacy",
+			Selector{
+				{Key: "zone", Operator: Equals, Values: []string{"factory-2"}},
+				{Key: "tier", Operator: In, Values: []string{"gpu", "npu"}},
+				{Key: "legacy", Operator: DoesNotExist},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := Parse(tt.selector)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.selector, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.selector, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	selectors := []string{
+		"zone in (factory-1",
+		"zone in (factory 1)",
+		"(zone)",
+		"bad key=x",
+		"=x",
+		"!=x",
+		"zone=a=b",
+		"zone=" + strings.Repeat("a", 64),
+		"-zone=a",
+		"Example.com/tier=gpu",
+		"/tier=gpu",
+		`zone="a"`,
+	}
+	for _, selector := range selectors {
+		t.Run(selector, func(t *testing.T) {
+			if got, err := Parse(selector); err == nil {
+				t.Errorf("Parse(%q) = %+v, want an error", selector, got)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	labels := map[string]string{"zone": "factory-2", "tier": "gpu", "gpu": ""}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"zone=factory-2", true},
+		{"zone=factory-1", false},
+		{"tier!=cpu", true},
+		{"tier!=gpu", false},
+		{"missing!=x", true},
+		{"zone in (factory-1,factory-2)", true},
+		{"missing in (x)", false},
+		{"tier notin (cpu,npu)", true},
+		{"tier notin (gpu)", false},
+		{"missing notin (x)", true},
+		{"gpu", true},
+		{"missing", false},
+		{"!legacy", true},
+		{"!tier", false},
+		{"zone=factory-2,tier in (gpu,npu),!legacy", true},
+		{"zone=factory-2,tier in (cpu,npu)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := Parse(tt.selector)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.selector, err)
+			}
+			if got := selector.Matches(labels); got != tt.want {
+				t.Errorf("%q matches %v = %v, want %v", tt.selector, labels, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package mango
+
+import (
+	"encoding/json"
+)
+
+// Condition is a fragment of a CouchDB Mango selector. Values are only ever encoded by encoding/json,
+// so caller input (IDs, hostnames...) can not escape the field it is compared with
+type Condition map[string]interface{}
+
+// -- FIELD OPERATORS
+func Eq(field string, value interface{}) Condition {
+	return Condition{field: Condition{"$eq": value}}
+}
+
+func Ne(field string, value interface{}) Condition {
+	return Condition{field: Condition{"$ne": value}}
+}
+
+func Gt(field string, value interface{}) Condition {
+	return Condition{field: Condition{"$gt": value}}
+}
+
+func Gte(field string, value interface{}) Condition {
+	return Condition{field: Condition{"$gte": value}}
+}
+
+func Lt(field string, value interface{}) Condition {
+	return Condition{field: Condition{"$lt": value}}
+}
+
+func Lte(field string, value interface{}) Condition {
+	return Condition{field: Condition{"$lte": value}}
+}
+
+// Range matches from <= field < to
+func Range(field string, from interface{}, to interface{}) Condition {
+	return Condition{field: Condition{"$gte": from, "$lt": to}}
+}
+
+func In(field string, values ...interface{}) Condition {
+	if values == nil {
+		values = []interface{}{}
+	}
+	return Condition{field: Condition{"$in": values}}
+}
+
+func Nin(field string, values ...interface{}) Condition {
+	if values == nil {
+		values = []interface{}{}
+	}
+	return Condition{field: Condition{"$nin": values}}
+}
+
+// All matches arrays containing every value
+func All(field string, values ...interface{}) Condition {
+	if values == nil {
+		values = []interface{}{}
+	}
+	return Condition{field: Condition{"$all": values}}
+}
+
+func Exists(field string, exists bool) Condition {
+	return Condition{field: Condition{"$exists": exists}}
+}
+
+// ElemMatch matches arrays with at least one element satisfying the condition (fields relative to the element)
+func ElemMatch(field string, condition Condition) Condition {
+	return Condition{field: Condition{"$elemMatch": condition}}
+}
+
+// AllMatch matches arrays where every element satisfies the condition (fields relative to the element)
+func AllMatch(field string, condition Condition) Condition {
+	return Condition{field: Condition{"$allMatch": condition}}
+}
+
+// -- COMBINATION OPERATORS
+func And(conditions ...Condition) Condition {
+	if conditions == nil {
+		conditions = []Condition{}
+	}
+	return Condition{"$and": conditions}
+}
+
+func Or(conditions ...Condition) Condition {
+	if conditions == nil {
+		conditions = []Condition{}
+	}
+	return Condition{"$or": conditions}
+}
+
+func Not(condition Condition) Condition {
+	return Condition{"$not": condition}
+}
+
+// -- QUERY
+type SortDirection string
+
+const (
+	Asc  SortDirection = "asc"
+	Desc SortDirection = "desc"
+)
+
+type Query struct {
+	Selector Condition                  `json:"selector"`
+	Sort     []map[string]SortDirection `json:"sort,omitempty"`
+	Limit    int                        `json:"limit,omitempty"`
+	Skip     int                        `json:"skip,omitempty"`
+	UseIndex string                     `json:"use_index,omitempty"`
+	Fields   []string                   `json:"fields,omitempty"`
+}
+
+// NewQuery creates a query matching every condition
+func NewQuery(conditions ...Condition) Query {
+	if len(conditions) == 0 {
+		return Query{Selector: Condition{}}
+	}
+	if len(conditions) == 1 {
+		return Query{Selector: conditions[0]}
+	}
+	return Query{Selector: And(conditions...)}
+}
+
+func (q Query) SortBy(field string, direction SortDirection) Query {
+	q.Sort = append(append([]map[string]SortDirection{}, q.Sort...), map[string]SortDirection{field: direction})
+	return q
+}
+
+func (q Query) WithLimit(limit int) Query {
+	q.Limit = limit
+	return q
+}
+
+func (q Query) WithSkip(skip int) Query {
+	q.Skip = skip
+	return q
+}
+
+func (q Query) WithIndex(index string) Query {
+	q.UseIndex = index
+	return q
+}
+
+func (q Query) WithFields(fields ...string) Query {
+	q.Fields = fields
+	return q
+}
+
+// Build returns the JSON query string expected by GetQueryResult
+func (q Query) Build() (string, error) {
+	if q.Selector == nil {
+		q.Selector = Condition{}
+	}
+	s, err := json.Marshal(q)
+	return string(s), err
+}
+
+func (q Query) String() string {
+	s, _ := q.Build()
+	return s
+}
@@ -0,0 +1,157 @@
+package mango
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// IDs trying to close the string or the object and inject another operator
+var maliciousIDs = []string{
+	`"`,
+	`}`,
+	`$or`,
+	`a"}}`,
+	`x"}, "$or": [{"_id": {"$gt": null}}], "y": {"$eq": "`,
+	`"}}, {"$or": [{"owner": {"$ne": ""}}]}, {"id": {"$eq": "`,
+	`{"$gt": null}`,
+	"\\\"\u0000</script>",
+}
+
+// selector builds the query, decodes it back and returns its selector
+func selector(t *testing.T, query Query) map[string]interface{} {
+	t.Helper()
+	built, err := query.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	var decoded struct {
+		Selector map[string]interface{} `json:"selector"`
+	}
+	err = json.Unmarshal([]byte(built), &decoded)
+	if err != nil {
+		t.Fatalf("the query %s is not valid JSON: %v", built, err)
+	}
+	return decoded.Selector
+}
+
+// operand returns the value of the only operator of the only field of the selector
+func operand(t *testing.T, selector map[string]interface{}, field string, operator string) interface{} {
+	t.Helper()
+	if len(selector) != 1 {
+		t.Fatalf("the selector has %d fields, want only %s: %v", len(selector), field, selector)
+	}
+	condition, ok := selector[field].(map[string]interface{})
+	if !ok {
+		t.Fatalf("the selector does not compare %s: %v", field, selector)
+	}
+	if len(condition) != 1 {
+		t.Fatalf("%s has %d operators, want only %s: %v", field, len(condition), operator, condition)
+	}
+	value, found := condition[operator]
+	if !found {
+		t.Fatalf("%s is not compared with %s: %v", field, operator, condition)
+	}
+	return value
+}
+
+func TestFieldOperatorsKeepInputInField(t *testing.T) {
+	builders := []struct {
+		operator string
+		build    func(field string, id string) Condition
+		want     func(id string) interface{}
+	}{
+		{"$eq", func(f string, id string) Condition { return Eq(f, id) }, func(id string) interface{} { return id }},
+		{"$ne", func(f string, id string) Condition { return Ne(f, id) }, func(id string) interface{} { return id }},
+		{"$gt", func(f string, id string) Condition { return Gt(f, id) }, func(id string) interface{} { return id }},
+		{"$gte", func(f string, id string) Condition { return Gte(f, id) }, func(id string) interface{} { return id }},
+		{"$lt", func(f string, id string) Condition { return Lt(f, id) }, func(id string) interface{} { return id }},
+		{"$lte", func(f string, id string) Condition { return Lte(f, id) }, func(id string) interface{} { return id }},
+		{"$in", func(f string, id string) Condition { return In(f, id) }, func(id string) interface{} { return []interface{}{id} }},
+		{"$nin", func(f string, id string) Condition { return Nin(f, id) }, func(id string) interface{} { return []interface{}{id} }},
+		{"$all", func(f string, id string) Condition { return All(f, id) }, func(id string) interface{} { return []interface{}{id} }},
+	}
+
+	for _, builder := range builders {
+		for _, id := range maliciousIDs {
+			t.Run(builder.operator+" "+id, func(t *testing.T) {
+				got := operand(t, selector(t, NewQuery(builder.build("id", id))), "id", builder.operator)
+				if want := builder.want(id); !reflect.DeepEqual(got, want) {
+					t.Errorf("%s = %#v, want the raw input %#v", builder.operator, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestRangeKeepsInputInField(t *testing.T) {
+	for _, id := range maliciousIDs {
+		s := selector(t, NewQuery(Range("id", id, id+"~")))
+		if len(s) != 1 {
+			t.Fatalf("the selector has %d fields, want only id: %v", len(s), s)
+		}
+		want := map[string]interface{}{"$gte": id, "$lt": id + "~"}
+		if got := s["id"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("id = %#v, want %#v", got, want)
+		}
+	}
+}
+
+func TestArrayOperatorsKeepInputInElement(t *testing.T) {
+	builders := []struct {
+		operator string
+		build    func(field string, condition Condition) Condition
+	}{
+		{"$elemMatch", ElemMatch},
+		{"$allMatch", AllMatch},
+	}
+
+	for _, builder := range builders {
+		for _, id := range maliciousIDs {
+			t.Run(builder.operator+" "+id, func(t *testing.T) {
+				element := operand(t, selector(t, NewQuery(builder.build("relations", Eq("to", id)))), "relations", builder.operator)
+				elementSelector, ok := element.(map[string]interface{})
+				if !ok {
+					t.Fatalf("%s is not a selector: %#v", builder.operator, element)
+				}
+				if got := operand(t, elementSelector, "to", "$eq"); got != id {
+					t.Errorf("$eq = %#v, want the raw input %#v", got, id)
+				}
+			})
+		}
+	}
+}
+
+func TestCombinationsKeepInputInFields(t *testing.T) {
+	for _, id := range maliciousIDs {
+		s := selector(t, NewQuery(Eq("type", 0), Not(Eq("id", id))))
+		if len(s) != 1 {
+			t.Fatalf("the selector has %d fields, want only $and: %v", len(s), s)
+		}
+		conditions, ok := s["$and"].([]interface{})
+		if !ok || len(conditions) != 2 {
+			t.Fatalf("$and = %#v, want 2 conditions", s["$and"])
+		}
+		not, ok := conditions[1].(map[string]interface{})
+		if !ok || len(not) != 1 {
+			t.Fatalf("$and[1] = %#v, want only $not", conditions[1])
+		}
+		negated, ok := not["$not"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("$not = %#v, want a selector", not["$not"])
+		}
+		if got := operand(t, negated, "id", "$eq"); got != id {
+			t.Errorf("$eq = %#v, want the raw input %#v", got, id)
+		}
+	}
+}
+
+func TestBuildEmptySelector(t *testing.T) {
+	built, err := Query{}.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if built != `{"selector":{}}` {
+		t.Errorf("Build() = %s, want an empty selector", built)
+	}
+}
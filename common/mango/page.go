@@ -0,0 +1,14 @@
+package mango
+
+import "github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+
+// MaxPageSize caps the page size requested by clients
+const MaxPageSize int32 = 1000
+
+// ValidatePageSize checks the page size of a paginated query
+func ValidatePageSize(pageSize int32) error {
+	if pageSize <= 0 || pageSize > MaxPageSize {
+		return errs.InvalidArgumentf("the page size must be between 1 and %d, got %d", MaxPageSize, pageSize)
+	}
+	return nil
+}
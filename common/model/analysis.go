@@ -0,0 +1,61 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/wI2L/jettison"
+)
+
+// -- LATENCY ANALYSIS (latency-sc)
+type LatencyAnalysis struct {
+	Hostname       string  `json:"hostname"`
+	Target         string  `json:"target"`
+	Duration       int     `json:"duration"`
+	AverageLatency float64 `json:"averageLatency"`
+	LatencyCount   int     `json:"latencyCount"`
+	LatencySummary []int64 `json:"statSummary"`
+}
+
+func (d LatencyAnalysis) String() string {
+	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
+	return string(s)
+}
+
+func LatencyAnalysisJsonToStruct(v string) (asset LatencyAnalysis, err error) {
+	err = json.Unmarshal([]byte(v), &asset)
+	return asset, err
+}
+
+// -- RESOURCE SUMMARY (resources-sc)
+type StatSummary struct {
+	ID                  string    `json:"id"`
+	Timestamp           Timestamp `json:"timestamp"`
+	CPUAverageUsage     float64   `json:"cpuAverageUsage"`
+	MemoryUsePercentage float64   `json:"memoryUsePercentage"`
+	ContainersRunning   int       `json:"containersRunning"`
+}
+
+func (d StatSummary) String() string {
+	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
+	return string(s)
+}
+
+// -- RESOURCE ANALYSIS (resources-sc)
+type StatAnalysis struct {
+	Hostname            string        `json:"hostname"`
+	Duration            int           `json:"duration"`
+	CPUAverageUsage     float64       `json:"cpuAverageUsage"`
+	MemoryUsePercentage float64       `json:"memoryUsePercentage"`
+	ContainersRunning   int           `json:"containersRunning"`
+	StatSummary         []StatSummary `json:"statSummary"`
+}
+
+func (d StatAnalysis) String() string {
+	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
+	return string(s)
+}
+
+func JsonToStatAnalysis(v string) (analysis StatAnalysis, err error) {
+	err = json.Unmarshal([]byte(v), &analysis)
+	return analysis, err
+}
@@ -0,0 +1,186 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// INVENTORY ASSET
+type Asset struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Owner        string            `json:"owner"`        //MSP ID of the owner organization, set from the submitting client
+	Type         int               `json:"type"`         //[0: Server, 1: Robot, 2: Sensor]
+	State        int               `json:"state"`        //[0: Provisioning, 1: Active, 2: Draining, 3: Maintenance, 4: Faulty, 5: Decommissioned] (see lifecycle.go)
+	Properties   Properties        `json:"properties"`   //{GPU: TRUE ...}
+	PendingOwner string            `json:"pendingOwner"` //MSP ID of the organization a transfer was proposed to, "" = no pending transfer
+	Labels       map[string]string `json:"labels"`       //Kubernetes-style labels, e.g. {zone: factory-2, tier: gpu} (see common/labels)
+	Location     Location          `json:"location"`     //site, building, zone and optional coordinates (see location.go)
+}
+
+// PROPERTY ASSET
+// Can be expanded to match the evolution of the PDP (Policy Decision Point) that determines how the Edge Server is selected
+// Updated from being a simple map[string]string because it would be difficult to index the results in CouchDB otherwise (data integrity)
+// Host credentials are not stored in plain text, they are kept in the private data collection of the owner organization
+// and the public asset only carries their hash (see Credentials)
+// Schema version 2 adds the capabilities used for placement (architecture, cores, memory, accelerators, software)
+type Properties struct {
+	SchemaVersion    int      `json:"schemaVersion"` //set by the Smart Contract, 0 = assets stored before versioning (version 1)
+	GPU              int      `json:"gpu"`           //0 = false, 1 = true
+	Hostname         string   `json:"hostname"`
+	HostPort         string   `json:"hostPort"`
+	CredentialsHash  string   `json:"credentialsHash"` //hex SHA-256 of the private salted Credentials, "" = no credentials
+	Arch             string   `json:"arch"`            //[amd64, arm64, armv7 ...]
+	CPUCores         int      `json:"cpuCores"`
+	RAMMB            int      `json:"ramMB"`
+	GPUModel         string   `json:"gpuModel"` //e.g. nvidia-jetson-xavier, "" = no GPU
+	GPUVRAMMB        int      `json:"gpuVramMB"`
+	Accelerators     []string `json:"accelerators"`     //[tpu, npu ...]
+	OS               string   `json:"os"`               //e.g. ubuntu-20.04
+	ContainerRuntime string   `json:"containerRuntime"` //[docker, containerd, podman ...]
+	Frameworks       []string `json:"frameworks"`       //e.g. [tensorflow, pytorch, ros2]
+	Tags             []string `json:"tags"`             //free-form capability tags, key/value labels are kept in Asset.Labels
+}
+
+// Version of Properties written by the Smart Contract
+const PropertiesSchemaVersion = 2
+
+// -- CAPABILITY REQUIREMENTS
+// Posted to FindAssetsByCapabilities. Zero values are not required, lists of "any" match one of the values and lists of "all" every value
+type CapabilityRequirements struct {
+	Types            []string `json:"types"` //names of the asset types, empty = every type
+	Arch             []string `json:"arch"`  //any
+	MinCPUCores      int      `json:"minCpuCores"`
+	MinRAMMB         int      `json:"minRamMB"`
+	GPU              bool     `json:"gpu"`
+	GPUModel         []string `json:"gpuModel"` //any
+	MinGPUVRAMMB     int      `json:"minGpuVramMB"`
+	Accelerators     []string `json:"accelerators"`     //all
+	OS               []string `json:"os"`               //any
+	ContainerRuntime []string `json:"containerRuntime"` //any
+	Frameworks       []string `json:"frameworks"`       //all
+	Tags             []string `json:"tags"`             //all
+	LabelSelector    string   `json:"labelSelector"`    //e.g. "zone=factory-2,tier in (gpu,npu)"
+}
+
+func JsonToCapabilityRequirements(v string) (requirements CapabilityRequirements, err error) {
+	err = json.Unmarshal([]byte(v), &requirements)
+	return requirements, err
+}
+
+// jettison can not encode non-empty maps (Labels), encoding/json is used instead
+func (d Asset) String() string {
+	s, _ := json.Marshal(d.normalized())
+	return string(s)
+}
+
+func AssetArrayToJson(d []Asset) []byte {
+	assets := make([]Asset, len(d))
+	for i, asset := range d {
+		assets[i] = asset.normalized()
+	}
+	s, _ := json.Marshal(assets)
+	return s
+}
+
+// CollectorHostname is the hostname the collectors identify the asset with, the asset ID when properties.hostname is not set
+func (d Asset) CollectorHostname() string {
+	if d.Properties.Hostname != "" {
+		return d.Properties.Hostname
+	}
+	return d.ID
+}
+
+// normalized encodes nil maps and slices as {} and [], as jettison did
+func (d Asset) normalized() Asset {
+	if d.Labels == nil {
+		d.Labels = map[string]string{}
+	}
+	if d.Properties.Accelerators == nil {
+		d.Properties.Accelerators = []string{}
+	}
+	if d.Properties.Frameworks == nil {
+		d.Properties.Frameworks = []string{}
+	}
+	if d.Properties.Tags == nil {
+		d.Properties.Tags = []string{}
+	}
+	return d
+}
+
+func JsonToAsset(v string) (asset Asset, err error) {
+	err = json.Unmarshal([]byte(v), &asset)
+	return asset, err
+}
+
+func JsonToProperties(v string) (properties Properties, err error) {
+	err = json.Unmarshal([]byte(v), &properties)
+	return properties, err
+}
+
+func JsonToAssetArray(v string) (assets []Asset, err error) {
+	err = json.Unmarshal([]byte(v), &assets)
+	return assets, err
+}
+
+// -- CREDENTIALS
+// Host credentials, submitted through the transient map and stored in the private data collection of the owner organization
+// with the asset ID as key. Only the hash is written to the public world state, salted with random bytes chosen by the client
+// (the chaincode can not generate them, every endorsing peer must write the same record) so it can not be attacked offline
+type Credentials struct {
+	Salt         string `json:"salt"` //hex random salt, set from the transient map
+	HostUser     string `json:"hostUser"`
+	HostPassword string `json:"hostPassword"`
+}
+
+// Keys of the transient map that carry the Credentials and their salt
+const (
+	CredentialsTransientKey     = "credentials"
+	CredentialsSaltTransientKey = "credentialsSalt"
+)
+
+// Smallest salt accepted, in bytes
+const MinCredentialsSaltBytes = 16
+
+// Bytes stored in the private data collection, the salt comes first
+func (d Credentials) Bytes() []byte {
+	s, _ := json.Marshal(d)
+	return s
+}
+
+func JsonToCredentials(v string) (credentials Credentials, err error) {
+	err = json.Unmarshal([]byte(v), &credentials)
+	return credentials, err
+}
+
+// SaltCredentials returns the credentials with the hex encoded salt
+func SaltCredentials(d Credentials, salt []byte) (Credentials, error) {
+	if len(salt) < MinCredentialsSaltBytes {
+		return Credentials{}, fmt.Errorf("the credentials salt must have at least %d random bytes, got %d", MinCredentialsSaltBytes, len(salt))
+	}
+	d.Salt = hex.EncodeToString(salt)
+	return d, nil
+}
+
+// HashCredentials returns the hex SHA-256 of the stored bytes (salt and credentials), the same hash returned by GetPrivateDataHash
+func HashCredentials(d Credentials) string {
+	hash := sha256.Sum256(d.Bytes())
+	return hex.EncodeToString(hash[:])
+}
+
+// -- PAGINATION
+// Page of inventory assets, the bookmark is sent back to fetch the next page
+type AssetPage struct {
+	Records      []Asset `json:"records"`
+	FetchedCount int32   `json:"fetchedCount"`
+	Bookmark     string  `json:"bookmark"`
+}
+
+func CreateAssetPage(records []Asset, fetchedCount int32, bookmark string) AssetPage {
+	if records == nil {
+		records = []Asset{}
+	}
+	return AssetPage{Records: records, FetchedCount: fetchedCount, Bookmark: bookmark}
+}
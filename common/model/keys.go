@@ -0,0 +1,95 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/wI2L/jettison"
+)
+
+// -- KEY MATERIAL
+// SSH public key or X.509 certificate fingerprint registered for an inventory asset, so collectors
+// can verify the servers they connect to. Stored under the composite key (assetKey, assetID, fingerprint)
+type AssetKey struct {
+	AssetID       string    `json:"assetID"`
+	Kind          string    `json:"kind"`        //[ssh, x509]
+	PublicKey     string    `json:"publicKey"`   //authorized_keys format (ssh only)
+	Fingerprint   string    `json:"fingerprint"` //ssh: SHA256:<base64>, x509: hex SHA-256 of the DER certificate
+	Usage         []string  `json:"usage"`       //[host, login, tls-server, tls-client]
+	NotBefore     int64     `json:"notBefore"`   //unix seconds, 0 = registration time
+	ExpiresAt     int64     `json:"expiresAt"`   //unix seconds, 0 = no expiry
+	Status        string    `json:"status"`      //[active, rotated, revoked]
+	ReplacedBy    string    `json:"replacedBy"`  //fingerprint of the key that replaced a rotated key
+	RevokedReason string    `json:"revokedReason"`
+	RegisteredBy  string    `json:"registeredBy"` //MSP ID of the submitting client
+	Timestamp     Timestamp `json:"timestamp"`    //last change
+}
+
+const (
+	AssetKeyObjectType = "assetKey"
+
+	KeyKindSSH  = "ssh"
+	KeyKindX509 = "x509"
+
+	KeyStatusActive  = "active"
+	KeyStatusRotated = "rotated"
+	KeyStatusRevoked = "revoked"
+)
+
+var KeyUsages = []string{"host", "login", "tls-server", "tls-client"}
+
+var x509Fingerprint = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+func (d AssetKey) String() string {
+	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
+	return string(s)
+}
+
+func JsonToAssetKey(v string) (key AssetKey, err error) {
+	err = json.Unmarshal([]byte(v), &key)
+	return key, err
+}
+
+// IsValid returns true when the key is active and now (unix seconds) is inside its validity period
+func (d AssetKey) IsValid(now int64) bool {
+	if d.Status != KeyStatusActive || now < d.NotBefore {
+		return false
+	}
+	return d.ExpiresAt == 0 || now < d.ExpiresAt
+}
+
+func (d AssetKey) HasUsage(usage string) bool {
+	for _, u := range d.Usage {
+		if u == usage {
+			return true
+		}
+	}
+	return false
+}
+
+// SSHFingerprint returns the SHA256 fingerprint of an authorized_keys line, as printed by ssh-keygen -l
+func SSHFingerprint(publicKey string) (string, error) {
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("the SSH public key must have the format: <type> <base64 key> [comment]")
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", fmt.Errorf("the SSH public key is not valid base64: %v", err)
+	}
+	hash := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(hash[:]), nil
+}
+
+// NormalizeX509Fingerprint accepts the hex SHA-256 of a certificate with or without colons, in any case
+func NormalizeX509Fingerprint(fingerprint string) (string, error) {
+	normalized := strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+	if !x509Fingerprint.MatchString(normalized) {
+		return "", fmt.Errorf("the X.509 fingerprint must be the hex SHA-256 of the certificate")
+	}
+	return normalized, nil
+}
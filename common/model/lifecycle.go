@@ -0,0 +1,107 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/wI2L/jettison"
+)
+
+// -- LIFECYCLE
+// States of an inventory asset. The values keep the previous meaning of Asset.State:
+// 0 (Disabled) is read as provisioning and 1 (Enabled) as active
+const (
+	StateProvisioning   = 0
+	StateActive         = 1
+	StateDraining       = 2
+	StateMaintenance    = 3
+	StateFaulty         = 4
+	StateDecommissioned = 5
+)
+
+var AssetStateNames = map[int]string{
+	StateProvisioning:   "provisioning",
+	StateActive:         "active",
+	StateDraining:       "draining",
+	StateMaintenance:    "maintenance",
+	StateFaulty:         "faulty",
+	StateDecommissioned: "decommissioned",
+}
+
+// States an asset can be created in
+var InitialStates = []int{StateProvisioning, StateActive}
+
+// States in which work can be placed on an asset, the asset queries only return these
+var PlaceableStates = []int{StateActive}
+
+// Allowed transitions, decommissioned is final
+var StateTransitions = map[int][]int{
+	StateProvisioning:   {StateActive, StateFaulty, StateDecommissioned},
+	StateActive:         {StateDraining, StateMaintenance, StateFaulty},
+	StateDraining:       {StateActive, StateMaintenance, StateFaulty, StateDecommissioned},
+	StateMaintenance:    {StateActive, StateFaulty, StateDecommissioned},
+	StateFaulty:         {StateMaintenance, StateDecommissioned},
+	StateDecommissioned: {},
+}
+
+// StateTransition is recorded every time an asset changes state.
+// Stored under the composite key (stateTransition, assetID, txID)
+type StateTransition struct {
+	AssetID     string    `json:"assetID"`
+	From        string    `json:"from"` //"" when the asset is created
+	To          string    `json:"to"`
+	Reason      string    `json:"reason"`
+	SubmittedBy string    `json:"submittedBy"` //MSP ID of the submitting client
+	TxID        string    `json:"txID"`
+	Timestamp   Timestamp `json:"timestamp"`
+}
+
+const StateTransitionObjectType = "stateTransition"
+
+func (d StateTransition) String() string {
+	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
+	return string(s)
+}
+
+func JsonToStateTransition(v string) (transition StateTransition, err error) {
+	err = json.Unmarshal([]byte(v), &transition)
+	return transition, err
+}
+
+// StateName returns the name of the state, unknown states are returned as their number
+func StateName(state int) string {
+	if name, found := AssetStateNames[state]; found {
+		return name
+	}
+	return fmt.Sprint(state)
+}
+
+func ParseAssetState(name string) (int, error) {
+	for state, stateName := range AssetStateNames {
+		if stateName == name {
+			return state, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown state %q", name)
+}
+
+func CanTransition(from int, to int) bool {
+	return containsState(StateTransitions[from], to)
+}
+
+func IsInitialState(state int) bool {
+	return containsState(InitialStates, state)
+}
+
+func IsPlaceableState(state int) bool {
+	return containsState(PlaceableStates, state)
+}
+
+func containsState(states []int, state int) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
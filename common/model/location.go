@@ -0,0 +1,30 @@
+package model
+
+// -- LOCATION
+// Physical placement of an inventory asset: site -> building -> zone, plus optional coordinates.
+// Assets are indexed under the composite keys (assetSite, site, building, zone, assetID) and
+// (assetGeo, one attribute per geohash character, assetID), so both lookups work without CouchDB
+type Location struct {
+	Site        string       `json:"site"`
+	Building    string       `json:"building"` //requires site
+	Zone        string       `json:"zone"`     //requires building
+	Coordinates *Coordinates `json:"coordinates"`
+	Geohash     string       `json:"geohash"` //set by the Smart Contract from the coordinates, "" = no coordinates
+}
+
+type Coordinates struct {
+	Lat       float64   `json:"lat"`
+	Lon       float64   `json:"lon"`
+	Timestamp Timestamp `json:"timestamp"` //last position update, set by the Smart Contract
+}
+
+const (
+	AssetSiteObjectType = "assetSite"
+	AssetGeoObjectType  = "assetGeo"
+)
+
+// Asset found by a radius query, with its distance in meters to the searched point
+type AssetDistance struct {
+	Asset    Asset   `json:"asset"`
+	Distance float64 `json:"distance"`
+}
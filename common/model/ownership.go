@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/wI2L/jettison"
+)
+
+// -- OWNERSHIP HISTORY
+// Every change of ownership of an inventory asset, including the transfers that were proposed and never accepted.
+// Stored under the composite key (ownershipRecord, assetID, txID)
+type OwnershipRecord struct {
+	AssetID     string    `json:"assetID"`
+	Event       string    `json:"event"` //[created, proposed, accepted, rejected, cancelled, deleted]
+	From        string    `json:"from"`  //MSP ID of the owner before the event
+	To          string    `json:"to"`    //MSP ID of the owner (or proposed owner) after the event
+	Reason      string    `json:"reason"`
+	SubmittedBy string    `json:"submittedBy"` //MSP ID of the submitting client
+	TxID        string    `json:"txID"`
+	Timestamp   Timestamp `json:"timestamp"`
+}
+
+const (
+	OwnershipRecordObjectType = "ownershipRecord"
+
+	OwnershipCreated   = "created"
+	OwnershipProposed  = "proposed"
+	OwnershipAccepted  = "accepted"
+	OwnershipRejected  = "rejected"
+	OwnershipCancelled = "cancelled"
+	OwnershipDeleted   = "deleted"
+)
+
+func (d OwnershipRecord) String() string {
+	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
+	return string(s)
+}
+
+func JsonToOwnershipRecord(v string) (record OwnershipRecord, err error) {
+	err = json.Unmarshal([]byte(v), &record)
+	return record, err
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/wI2L/jettison"
+)
+
+// -- RELATIONSHIPS
+// Typed edge between two inventory assets, e.g. a sensor attachedTo a gateway or a robot hostedOn an edge server.
+// Stored in both directions, under the composite keys (assetRelation, from, type, to) and (assetRelationIn, to, type, from)
+type Relationship struct {
+	From      string    `json:"from"`
+	Type      string    `json:"type"` //[attachedTo, hostedOn, pairedWith]
+	To        string    `json:"to"`
+	CreatedBy string    `json:"createdBy"` //MSP ID of the submitting client
+	TxID      string    `json:"txID"`
+	Timestamp Timestamp `json:"timestamp"`
+}
+
+const (
+	RelationshipObjectType   = "assetRelation"
+	RelationshipInObjectType = "assetRelationIn"
+
+	RelationAttachedTo = "attachedTo"
+	RelationHostedOn   = "hostedOn"
+	RelationPairedWith = "pairedWith" //symmetric, A pairedWith B is also B pairedWith A
+
+	// Directions in which the edges of an asset are followed
+	DirectionOut  = "out"  //edges from the asset
+	DirectionIn   = "in"   //edges to the asset
+	DirectionBoth = "both" //default
+
+	// What happens to the edges of a deleted asset
+	RelationsRefuse  = "refuse"  //the asset can not be deleted while it has edges (default)
+	RelationsCascade = "cascade" //the edges are deleted with the asset
+)
+
+var RelationTypes = []string{RelationAttachedTo, RelationHostedOn, RelationPairedWith}
+
+func (d Relationship) String() string {
+	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
+	return string(s)
+}
+
+func JsonToRelationship(v string) (relationship Relationship, err error) {
+	err = json.Unmarshal([]byte(v), &relationship)
+	return relationship, err
+}
+
+// Neighbour returns the asset at the other end of the edge
+func (d Relationship) Neighbour(assetID string) string {
+	if d.From == assetID {
+		return d.To
+	}
+	return d.From
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/wI2L/jettison"
+)
+
+// -- TIMESTAMP
+// Shared by the collector payloads (resources and latency) and the records created by the Smart Contracts
+type Timestamp struct {
+	TimeLocal   time.Time `json:"timeLocal"`
+	TimeSeconds int64     `json:"timeSeconds"`
+	TimeNano    int64     `json:"timeNano"`
+}
+
+func (d Timestamp) String() string {
+	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
+	return string(s)
+}
+
+func CreateTimestamp(t time.Time) Timestamp {
+	return Timestamp{
+		TimeLocal:   t,
+		TimeSeconds: t.Unix(),
+		TimeNano:    t.UnixNano(),
+	}
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/wI2L/jettison"
+)
+
+// -- ASSET TYPES
+// Kinds of inventory assets (servers, robots, sensors, drones, gateways, cameras ...), kept in an on-ledger registry
+// under the composite key (assetType, name). Asset.Type holds the code of the type
+type AssetType struct {
+	Code              int      `json:"code"` //value of Asset.Type
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	AllowedProperties []string `json:"allowedProperties"` //Properties (json names) the assets of this type can set
+	Index             string   `json:"index"`             //CouchDB index (design document) used to query this type, "" = chosen by CouchDB
+}
+
+const (
+	AssetTypeObjectType = "assetType"
+
+	TypeServer = 0
+	TypeRobot  = 1
+	TypeSensor = 2
+)
+
+// Properties that can be set by the client, schemaVersion and credentialsHash are always set by the Smart Contract
+var PropertyNames = []string{"gpu", "hostname", "hostPort", "arch", "cpuCores", "ramMB", "gpuModel", "gpuVramMB",
+	"accelerators", "os", "containerRuntime", "frameworks", "tags"}
+
+// Types available before any type is registered, stored in the registry by InitLedger.
+// They allow every property, as the assets created before the registry did
+var DefaultAssetTypes = []AssetType{
+	{Code: TypeServer, Name: "server", Description: "Edge Server", AllowedProperties: PropertyNames},
+	{Code: TypeRobot, Name: "robot", Description: "Mobile robot", AllowedProperties: PropertyNames},
+	{Code: TypeSensor, Name: "sensor", Description: "Sensor node", AllowedProperties: PropertyNames},
+}
+
+func (d AssetType) String() string {
+	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
+	return string(s)
+}
+
+func JsonToAssetType(v string) (assetType AssetType, err error) {
+	err = json.Unmarshal([]byte(v), &assetType)
+	return assetType, err
+}
+
+func (d AssetType) AllowsProperty(property string) bool {
+	for _, p := range d.AllowedProperties {
+		if p == property {
+			return true
+		}
+	}
+	return false
+}
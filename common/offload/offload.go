@@ -0,0 +1,50 @@
+package offload
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Content-addressed storage of the payloads kept off the ledger (full resource stats).
+// Payloads are stored under the hex SHA-256 of their bytes, the ledger only keeps the hash, size and URI,
+// so anyone holding the payload can check it against the ledger. The collector uploads the payload to a Store
+// before submitting the transaction, the Smart Contracts never access the store
+
+var hashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type Store interface {
+	// Put stores the payload and returns its URI, storing the same payload twice returns the same URI
+	Put(payload []byte) (string, error)
+	// Get returns the payload of the URI, failing when the stored bytes do not match their hash
+	Get(uri string) ([]byte, error)
+}
+
+// Hash returns the hex SHA-256 of the payload, its address in the store
+func Hash(payload []byte) string {
+	hash := sha256.Sum256(payload)
+	return hex.EncodeToString(hash[:])
+}
+
+// HashFromURI returns the hash addressing the URI, its last path element (e.g. s3://bucket/sha256/<hash>)
+func HashFromURI(uri string) (string, error) {
+	hash := uri[strings.LastIndex(uri, "/")+1:]
+	if !hashPattern.MatchString(hash) {
+		return "", fmt.Errorf("the URI %q is not content addressed, it must end with the hex SHA-256 of the payload", uri)
+	}
+	return hash, nil
+}
+
+// verify checks the payload read from uri against the hash of the URI
+func verify(uri string, payload []byte) ([]byte, error) {
+	hash, err := HashFromURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	if Hash(payload) != hash {
+		return nil, fmt.Errorf("the payload stored at %s does not match its hash", uri)
+	}
+	return payload, nil
+}
@@ -0,0 +1,81 @@
+package offload
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	// SHA-256 TEST VECTORS
+	tests := []struct {
+		payload string
+		want    string
+	}{
+		{"", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	}
+	for _, tt := range tests {
+		if got := Hash([]byte(tt.payload)); got != tt.want {
+			t.Errorf("Hash(%q) = %s, want %s", tt.payload, got, tt.want)
+		}
+	}
+}
+
+func TestHashFromURI(t *testing.T) {
+	hash := Hash([]byte("abc"))
+	valid := []string{
+		"s3://bucket/sha256/" + hash,
+		"file:///var/offload/" + hash,
+		hash,
+	}
+	for _, uri := range valid {
+		got, err := HashFromURI(uri)
+		if err != nil {
+			t.Errorf("HashFromURI(%q): %v", uri, err)
+		} else if got != hash {
+			t.Errorf("HashFromURI(%q) = %s, want %s", uri, got, hash)
+		}
+	}
+
+	malformed := []string{
+		"",
+		"s3://bucket/sha256/",
+		"s3://bucket/sha256/" + hash[:63],
+		"s3://bucket/sha256/" + hash + "0",
+		"s3://bucket/sha256/" + strings.ToUpper(hash),
+		"s3://bucket/sha256/" + hash + "/",
+		"s3://bucket/sha256/" + hash + "?v=1",
+	}
+	for _, uri := range malformed {
+		if _, err := HashFromURI(uri); err == nil {
+			t.Errorf("HashFromURI(%q) accepted a URI that is not content addressed", uri)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	payload := []byte(`{"host":{"hostname":"h"}}`)
+	uri := "s3://bucket/sha256/" + Hash(payload)
+	tests := []struct {
+		name    string
+		uri     string
+		payload []byte
+		valid   bool
+	}{
+		{"matching payload", uri, payload, true},
+		{"tampered payload", uri, []byte(`{"host":{"hostname":"x"}}`), false},
+		{"truncated payload", uri, payload[:len(payload)-1], false},
+		{"malformed URI", "s3://bucket/sha256/payload", payload, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verify(tt.uri, tt.payload)
+			if tt.valid && (err != nil || string(got) != string(tt.payload)) {
+				t.Errorf("verify = %q, %v, want the payload", got, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("verify accepted %q for %s", tt.payload, tt.uri)
+			}
+		})
+	}
+}
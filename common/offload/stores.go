@@ -0,0 +1,114 @@
+package offload
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// -- LOCAL FILESYSTEM
+// FileStore keeps the payloads in Dir, URIs have the format file://<Dir>/<hash>
+type FileStore struct {
+	Dir string
+}
+
+func (f FileStore) Put(payload []byte) (string, error) {
+	hash := Hash(payload)
+	path := filepath.Join(f.Dir, hash)
+	uri := "file://" + filepath.ToSlash(path)
+	if _, err := os.Stat(path); err == nil {
+		return uri, nil
+	}
+	err := os.MkdirAll(f.Dir, 0o755)
+	if err != nil {
+		return "", err
+	}
+	// WRITTEN TO A TEMPORARY FILE FIRST, READERS NEVER SEE A PARTIAL PAYLOAD
+	tmp, err := os.CreateTemp(f.Dir, hash+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = tmp.Write(payload)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return uri, os.Rename(tmp.Name(), path)
+}
+
+func (f FileStore) Get(uri string) ([]byte, error) {
+	if !strings.HasPrefix(uri, "file://") {
+		return nil, fmt.Errorf("the URI %q is not a file URI", uri)
+	}
+	payload, err := os.ReadFile(filepath.FromSlash(strings.TrimPrefix(uri, "file://")))
+	if err != nil {
+		return nil, err
+	}
+	return verify(uri, payload)
+}
+
+// -- S3 COMPATIBLE
+// ObjectClient is the subset of an S3-compatible API used by S3Store, adapt the client of your object storage to it
+type ObjectClient interface {
+	PutObject(bucket string, key string, body []byte) error
+	GetObject(bucket string, key string) ([]byte, error)
+}
+
+// S3Store keeps the payloads in Bucket under sha256/<hash>, URIs have the format s3://<Bucket>/sha256/<hash>
+type S3Store struct {
+	Client ObjectClient
+	Bucket string
+}
+
+func (s S3Store) Put(payload []byte) (string, error) {
+	key := "sha256/" + Hash(payload)
+	err := s.Client.PutObject(s.Bucket, key, payload)
+	if err != nil {
+		return "", err
+	}
+	return "s3://" + s.Bucket + "/" + key, nil
+}
+
+func (s S3Store) Get(uri string) ([]byte, error) {
+	prefix := "s3://" + s.Bucket + "/"
+	if !strings.HasPrefix(uri, prefix) {
+		return nil, fmt.Errorf("the URI %q is not in the bucket %s", uri, s.Bucket)
+	}
+	payload, err := s.Client.GetObject(s.Bucket, strings.TrimPrefix(uri, prefix))
+	if err != nil {
+		return nil, err
+	}
+	return verify(uri, payload)
+}
+
+// MemoryObjectClient is an in-memory stand-in for an S3-compatible object storage
+type MemoryObjectClient struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+}
+
+func NewMemoryObjectClient() *MemoryObjectClient {
+	return &MemoryObjectClient{objects: make(map[string][]byte)}
+}
+
+func (m *MemoryObjectClient) PutObject(bucket string, key string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[bucket+"/"+key] = append([]byte(nil), body...)
+	return nil
+}
+
+func (m *MemoryObjectClient) GetObject(bucket string, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	body, found := m.objects[bucket+"/"+key]
+	if !found {
+		return nil, fmt.Errorf("the object %s/%s does not exist", bucket, key)
+	}
+	return append([]byte(nil), body...), nil
+}
@@ -0,0 +1,110 @@
+package offload
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tamper replaces the payload stored at uri
+type tamper func(t *testing.T, uri string, payload []byte)
+
+func storeTests(t *testing.T) map[string]struct {
+	store  Store
+	prefix string
+	tamper tamper
+} {
+	dir := t.TempDir()
+	client := NewMemoryObjectClient()
+	return map[string]struct {
+		store  Store
+		prefix string
+		tamper tamper
+	}{
+		"FileStore": {
+			store:  FileStore{Dir: filepath.Join(dir, "offload")},
+			prefix: "file://" + filepath.ToSlash(filepath.Join(dir, "offload")) + "/",
+			tamper: func(t *testing.T, uri string, payload []byte) {
+				err := os.WriteFile(filepath.FromSlash(strings.TrimPrefix(uri, "file://")), payload, 0o644)
+				if err != nil {
+					t.Fatalf("WriteFile: %v", err)
+				}
+			},
+		},
+		"S3Store": {
+			store:  S3Store{Client: client, Bucket: "stats"},
+			prefix: "s3://stats/sha256/",
+			tamper: func(t *testing.T, uri string, payload []byte) {
+				err := client.PutObject("stats", strings.TrimPrefix(uri, "s3://stats/"), payload)
+				if err != nil {
+					t.Fatalf("PutObject: %v", err)
+				}
+			},
+		},
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	payloads := [][]byte{[]byte(`{"host":{"hostname":"h"}}`), {}, {0, 1, 2, 255}}
+	for name, tt := range storeTests(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, payload := range payloads {
+				uri, err := tt.store.Put(payload)
+				if err != nil {
+					t.Fatalf("Put: %v", err)
+				}
+				if want := tt.prefix + Hash(payload); uri != want {
+					t.Errorf("Put returned %s, want %s", uri, want)
+				}
+				// THE SAME PAYLOAD IS STORED UNDER THE SAME URI
+				again, err := tt.store.Put(payload)
+				if err != nil || again != uri {
+					t.Errorf("Put of the same payload returned %s, %v, want %s", again, err, uri)
+				}
+				got, err := tt.store.Get(uri)
+				if err != nil {
+					t.Fatalf("Get(%s): %v", uri, err)
+				}
+				if string(got) != string(payload) {
+					t.Errorf("Get(%s) = %q, want %q", uri, got, payload)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreRejectsTamperedPayload(t *testing.T) {
+	for name, tt := range storeTests(t) {
+		t.Run(name, func(t *testing.T) {
+			uri, err := tt.store.Put([]byte(`{"cpuStats":{"averageUsage":10}}`))
+			if err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			tt.tamper(t, uri, []byte(`{"cpuStats":{"averageUsage":90}}`))
+			if got, err := tt.store.Get(uri); err == nil {
+				t.Errorf("Get returned the tampered payload %q", got)
+			}
+		})
+	}
+}
+
+func TestStoreRejectsForeignURIs(t *testing.T) {
+	hash := Hash([]byte("abc"))
+	for name, tt := range storeTests(t) {
+		t.Run(name, func(t *testing.T) {
+			uris := []string{
+				"",
+				"https://example.com/" + hash,
+				"s3://other/sha256/" + hash,
+				tt.prefix + "missing",
+				tt.prefix + hash,
+			}
+			for _, uri := range uris {
+				if got, err := tt.store.Get(uri); err == nil {
+					t.Errorf("Get(%q) = %q, want an error", uri, got)
+				}
+			}
+		})
+	}
+}
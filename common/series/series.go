@@ -0,0 +1,248 @@
+package series
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/clock"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/mango"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Time series of samples (resource stats, latency results) stored under the composite key (objectType, host, time),
+// time being the zero-padded unix nanoseconds of the sample, so the keys of a host sort by time.
+// The queries are paginated scans of the partial composite key of the host starting at the key of their window:
+// they work on LevelDB and CouchDB without indexes, only read the keys of the window and are re-validated at commit
+
+// Largest number of samples returned by Last
+const MaxSamples = 1000
+
+// TimeAttribute returns the time attribute of the key, 19 digits hold every positive int64
+func TimeAttribute(timestamp model.Timestamp) string {
+	nanos := timestamp.TimeNano
+	if nanos == 0 {
+		nanos = timestamp.TimeSeconds * 1e9
+	}
+	return fmt.Sprintf("%019d", nanos)
+}
+
+func Key(stub shim.ChaincodeStubInterface, objectType string, host string, timestamp model.Timestamp) (string, error) {
+	if timestamp.TimeSeconds <= 0 && timestamp.TimeNano <= 0 {
+		return "", errs.InvalidArgumentf("the sample of %s has no timestamp", host)
+	}
+	key, err := stub.CreateCompositeKey(objectType, []string{host, TimeAttribute(timestamp)})
+	if err != nil {
+		return "", errs.InvalidArgumentf("invalid key: %v", err)
+	}
+	return key, nil
+}
+
+// IndexKey returns the key of an index entry of the host (e.g. the target of a latency measurement) under the composite key
+// (objectType, host, time, ref), ref telling apart the entries of the same time. Index entries are read with Between and Last as samples
+func IndexKey(stub shim.ChaincodeStubInterface, objectType string, host string, timestamp model.Timestamp, ref string) (string, error) {
+	if timestamp.TimeSeconds <= 0 && timestamp.TimeNano <= 0 {
+		return "", errs.InvalidArgumentf("the sample of %s has no timestamp", host)
+	}
+	key, err := stub.CreateCompositeKey(objectType, []string{host, TimeAttribute(timestamp), ref})
+	if err != nil {
+		return "", errs.InvalidArgumentf("invalid key: %v", err)
+	}
+	return key, nil
+}
+
+// Between returns the samples of the host with fromSeconds <= time < toSeconds, oldest first.
+// The scan starts at the key of fromSeconds and stops at the first key of toSeconds
+func Between(stub shim.ChaincodeStubInterface, objectType string, host string, fromSeconds int64, toSeconds int64) ([]string, error) {
+	err := clock.ValidateWindow(fromSeconds, toSeconds)
+	if err != nil {
+		return nil, err
+	}
+	to := TimeAttribute(model.Timestamp{TimeSeconds: toSeconds})
+
+	samples := []string{}
+	err = scan(stub, objectType, host, TimeAttribute(model.Timestamp{TimeSeconds: fromSeconds}), func(at string, value []byte) bool {
+		if at >= to {
+			return false
+		}
+		samples = append(samples, string(value))
+		return true
+	})
+	return samples, err
+}
+
+// BetweenPage returns a page of Between and the bookmark of the next page, "" after the last page.
+// The bookmark is the key the next page starts from, an empty bookmark starts from fromSeconds
+func BetweenPage(stub shim.ChaincodeStubInterface, objectType string, host string, fromSeconds int64, toSeconds int64, pageSize int32, bookmark string) ([]string, string, error) {
+	err := clock.ValidateWindow(fromSeconds, toSeconds)
+	if err != nil {
+		return nil, "", err
+	}
+	err = mango.ValidatePageSize(pageSize)
+	if err != nil {
+		return nil, "", err
+	}
+	from := TimeAttribute(model.Timestamp{TimeSeconds: fromSeconds})
+	to := TimeAttribute(model.Timestamp{TimeSeconds: toSeconds})
+	if bookmark == "" {
+		bookmark, err = stub.CreateCompositeKey(objectType, []string{host, from})
+		if err != nil {
+			return nil, "", errs.InvalidArgumentf("invalid key: %v", err)
+		}
+	} else {
+		// THE BOOKMARK IS THE START KEY, IT MUST NOT LEAVE THE WINDOW OF THE HOST
+		if bookmark[0] != 0 {
+			return nil, "", errs.InvalidArgumentf("invalid bookmark %q", bookmark)
+		}
+		bookmarkType, attributes, err := stub.SplitCompositeKey(bookmark)
+		if err != nil || bookmarkType != objectType || len(attributes) < 2 || attributes[0] != host || attributes[1] < from || attributes[1] >= to {
+			return nil, "", errs.InvalidArgumentf("invalid bookmark %q", bookmark)
+		}
+	}
+
+	samples := []string{}
+	next, err := scanPage(stub, objectType, host, pageSize, bookmark, func(at string, value []byte) bool {
+		if at >= to {
+			return false
+		}
+		samples = append(samples, string(value))
+		return true
+	})
+	if err != nil || next == "" {
+		return samples, "", err
+	}
+	_, attributes, err := stub.SplitCompositeKey(next)
+	if err != nil || len(attributes) < 2 || attributes[1] >= to {
+		return samples, "", nil
+	}
+	return samples, next, nil
+}
+
+// Last returns the n latest samples of the host, newest first.
+// Keys can only be read forwards: the scan starts one minute before the transaction timestamp and the window
+// doubles until it holds n samples or the oldest sample of the host, so the cost does not grow with its history
+func Last(stub shim.ChaincodeStubInterface, objectType string, host string, n int) ([]string, error) {
+	if n < 1 || n > MaxSamples {
+		return nil, errs.InvalidArgumentf("the number of samples must be between 1 and %d: %d", MaxSamples, n)
+	}
+
+	oldest := ""
+	_, err := scanPage(stub, objectType, host, 1, "", func(at string, value []byte) bool {
+		oldest = at
+		return false
+	})
+	if err != nil || oldest == "" {
+		return []string{}, err
+	}
+	now, err := clock.New(stub).Now()
+	if err != nil {
+		return nil, err
+	}
+
+	for window := time.Minute; ; window *= 2 {
+		from := TimeAttribute(model.Timestamp{TimeNano: now.Add(-window).UnixNano()})
+		if now.Add(-window).UnixNano() <= 0 || from <= oldest {
+			from = oldest
+		}
+		// KEEPS THE LAST N VALUES IN A RING, KEYS ARE READ OLDEST FIRST
+		ring := make([]string, 0, n)
+		next := 0
+		err = scan(stub, objectType, host, from, func(at string, value []byte) bool {
+			if len(ring) < n {
+				ring = append(ring, string(value))
+			} else {
+				ring[next] = string(value)
+			}
+			next = (next + 1) % n
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(ring) < n && from > oldest {
+			continue
+		}
+
+		samples := make([]string, 0, len(ring))
+		for i := 1; i <= len(ring); i++ {
+			samples = append(samples, ring[(next-i+n)%n])
+		}
+		return samples, nil
+	}
+}
+
+// Page returns a page of the samples of the host, oldest first, or of every host when host is empty.
+// An empty bookmark starts from the first sample
+func Page(stub shim.ChaincodeStubInterface, objectType string, host string, pageSize int32, bookmark string) ([]string, int32, string, error) {
+	err := mango.ValidatePageSize(pageSize)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	attributes := []string{}
+	if host != "" {
+		attributes = append(attributes, host)
+	}
+	resultsIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(objectType, attributes, pageSize, bookmark)
+	if err != nil {
+		return nil, 0, "", errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	samples := []string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, 0, "", errs.Internalf("failed to read query results: %v", err)
+		}
+		samples = append(samples, string(queryResponse.Value))
+	}
+	return samples, metadata.FetchedRecordsCount, metadata.Bookmark, nil
+}
+
+// Number of keys read per page by scan
+const scanPageSize int32 = 100
+
+// scan calls visit for every sample of the host from the time attribute from, oldest first, until visit returns false
+func scan(stub shim.ChaincodeStubInterface, objectType string, host string, from string, visit func(at string, value []byte) bool) error {
+	bookmark, err := stub.CreateCompositeKey(objectType, []string{host, from})
+	if err != nil {
+		return errs.InvalidArgumentf("invalid key: %v", err)
+	}
+	for bookmark != "" {
+		bookmark, err = scanPage(stub, objectType, host, scanPageSize, bookmark, visit)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanPage calls visit for the samples of a page of the host, oldest first, and returns the bookmark of the next page,
+// "" after the last page or when visit returns false. GetStateByRange refuses composite keys, so ranges are read as pages
+// of the partial composite key: the bookmark of a range query is the key the page starts from, "" for the oldest sample
+func scanPage(stub shim.ChaincodeStubInterface, objectType string, host string, pageSize int32, bookmark string, visit func(at string, value []byte) bool) (string, error) {
+	resultsIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(objectType, []string{host}, pageSize, bookmark)
+	if err != nil {
+		return "", errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return "", errs.Internalf("failed to read query results: %v", err)
+		}
+		_, attributes, err := stub.SplitCompositeKey(queryResponse.Key)
+		if err != nil || len(attributes) < 2 {
+			return "", errs.Internalf("invalid sample key: %s", queryResponse.Key)
+		}
+		if !visit(attributes[1], queryResponse.Value) {
+			return "", nil
+		}
+	}
+	if metadata == nil || metadata.FetchedRecordsCount < pageSize {
+		return "", nil
+	}
+	return metadata.Bookmark, nil
+}
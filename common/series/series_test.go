@@ -0,0 +1,287 @@
+package series
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// pagingStub adds the paginated range queries MockStub lacks, as Fabric runs them: a page starts at the bookmark
+// and its bookmark is the key of the next page. reads counts the keys read
+type pagingStub struct {
+	*shimtest.MockStub
+	reads int
+}
+
+func newStub(now int64) *pagingStub {
+	stub := &pagingStub{MockStub: shimtest.NewMockStub("series", nil)}
+	stub.TxTimestamp = &timestamp.Timestamp{Seconds: now}
+	return stub
+}
+
+func (s *pagingStub) GetStateByPartialCompositeKeyWithPagination(objectType string, attributes []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	start, err := s.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, nil, err
+	}
+	end := start + string(utf8.MaxRune)
+	if bookmark != "" {
+		start = bookmark
+	}
+	iterator := shimtest.NewMockStateRangeQueryIterator(s.MockStub, start, end)
+	page := &kvIterator{}
+	next := ""
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if int32(len(page.kvs)) == pageSize {
+			next = kv.Key
+			break
+		}
+		page.kvs = append(page.kvs, kv)
+	}
+	s.reads += len(page.kvs)
+	return page, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(page.kvs)), Bookmark: next}, nil
+}
+
+type kvIterator struct {
+	kvs []*queryresult.KV
+}
+
+func (it *kvIterator) HasNext() bool { return len(it.kvs) > 0 }
+func (it *kvIterator) Close() error  { return nil }
+func (it *kvIterator) Next() (*queryresult.KV, error) {
+	kv := it.kvs[0]
+	it.kvs = it.kvs[1:]
+	return kv, nil
+}
+
+func TestTimeAttribute(t *testing.T) {
+	tests := []struct {
+		timestamp model.Timestamp
+		want      string
+	}{
+		{model.Timestamp{TimeSeconds: 1}, "0000000001000000000"},
+		{model.Timestamp{TimeSeconds: 1, TimeNano: 1500000000}, "0000000001500000000"},
+		{model.Timestamp{TimeNano: 42}, "0000000000000000042"},
+	}
+	for _, tt := range tests {
+		if got := TimeAttribute(tt.timestamp); got != tt.want {
+			t.Errorf("TimeAttribute(%+v) = %s, want %s", tt.timestamp, got, tt.want)
+		}
+	}
+	// THE KEYS OF A HOST SORT BY TIME ONLY IF THE ATTRIBUTES SORT AS STRINGS
+	if TimeAttribute(model.Timestamp{TimeSeconds: 9}) >= TimeAttribute(model.Timestamp{TimeSeconds: 10}) {
+		t.Error("time attributes do not sort by time")
+	}
+}
+
+func TestKeyWithoutTimestamp(t *testing.T) {
+	stub := newStub(1000)
+	if _, err := Key(stub, "sample", "h", model.Timestamp{}); err == nil {
+		t.Error("Key accepted a sample without timestamp")
+	}
+	if _, err := IndexKey(stub, "index", "h", model.Timestamp{}, "ref"); err == nil {
+		t.Error("IndexKey accepted a sample without timestamp")
+	}
+}
+
+// putSamples stores a sample named host@time for every time of the host
+func putSamples(t *testing.T, stub *pagingStub, host string, seconds ...int64) {
+	t.Helper()
+	// MockTransactionStart RESETS THE TRANSACTION TIMESTAMP TO THE CLOCK OF THE TEST
+	defer func(txTimestamp *timestamp.Timestamp) { stub.TxTimestamp = txTimestamp }(stub.TxTimestamp)
+	stub.MockTransactionStart("put")
+	defer stub.MockTransactionEnd("put")
+	for _, s := range seconds {
+		key, err := Key(stub, "sample", host, model.Timestamp{TimeSeconds: s})
+		if err != nil {
+			t.Fatalf("Key: %v", err)
+		}
+		err = stub.PutState(key, []byte(sample(host, s)))
+		if err != nil {
+			t.Fatalf("PutState: %v", err)
+		}
+	}
+}
+
+func sample(host string, seconds int64) string {
+	return host + "@" + TimeAttribute(model.Timestamp{TimeSeconds: seconds})
+}
+
+// history returns count times one second apart ending at last
+func history(last int64, count int) []int64 {
+	seconds := []int64{}
+	for i := count - 1; i >= 0; i-- {
+		seconds = append(seconds, last-int64(i))
+	}
+	return seconds
+}
+
+func TestLast(t *testing.T) {
+	now := int64(1700000000)
+	stub := newStub(now)
+	// STORED OUT OF ORDER, WITH HOSTS SHARING A PREFIX
+	putSamples(t, stub, "h", now-30, now-100000, now-20)
+	putSamples(t, stub, "h1", now-25)
+	putSamples(t, stub, "g", now-40)
+
+	tests := []struct {
+		host string
+		n    int
+		want []string
+	}{
+		{"h", 1, []string{sample("h", now-20)}},
+		{"h", 2, []string{sample("h", now-20), sample("h", now-30)}},
+		{"h", MaxSamples, []string{sample("h", now-20), sample("h", now-30), sample("h", now-100000)}},
+		{"h1", 5, []string{sample("h1", now-25)}},
+		{"unknown", 5, []string{}},
+	}
+	for _, tt := range tests {
+		got, err := Last(stub, "sample", tt.host, tt.n)
+		if err != nil {
+			t.Fatalf("Last(%s, %d): %v", tt.host, tt.n, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Last(%s, %d) = %v, want %v", tt.host, tt.n, got, tt.want)
+		}
+	}
+
+	for _, n := range []int{0, -1, MaxSamples + 1} {
+		if _, err := Last(stub, "sample", "h", n); err == nil {
+			t.Errorf("Last accepted n = %d", n)
+		}
+	}
+}
+
+func TestLastDoesNotReadTheHistory(t *testing.T) {
+	now := int64(1700000000)
+	stub := newStub(now)
+	putSamples(t, stub, "h", history(now-int64(24*time.Hour/time.Second), 5000)...)
+	putSamples(t, stub, "h", now-10, now-5)
+
+	got, err := Last(stub, "sample", "h", 2)
+	if err != nil {
+		t.Fatalf("Last: %v", err)
+	}
+	if want := []string{sample("h", now-5), sample("h", now-10)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Last = %v, want %v", got, want)
+	}
+	// THE OLDEST KEY AND THE KEYS OF THE LAST MINUTE
+	if stub.reads > 3 {
+		t.Errorf("Last read %d keys, want at most 3", stub.reads)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	stub := newStub(1000)
+	putSamples(t, stub, "h", 10, 20, 30)
+	putSamples(t, stub, "h1", 20)
+
+	tests := []struct {
+		from, to int64
+		want     []string
+	}{
+		{0, 100, []string{sample("h", 10), sample("h", 20), sample("h", 30)}},
+		{20, 30, []string{sample("h", 20)}},
+		{11, 20, []string{}},
+	}
+	for _, tt := range tests {
+		got, err := Between(stub, "sample", "h", tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("Between(%d, %d): %v", tt.from, tt.to, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Between(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	if _, err := Between(stub, "sample", "h", 30, 20); err == nil {
+		t.Error("Between accepted a window ending before it starts")
+	}
+}
+
+func TestBetweenOnlyReadsTheWindow(t *testing.T) {
+	stub := newStub(1000000)
+	putSamples(t, stub, "h", history(100000, 5000)...)
+
+	got, err := Between(stub, "sample", "h", 99000, 99010)
+	if err != nil {
+		t.Fatalf("Between: %v", err)
+	}
+	if len(got) != 10 {
+		t.Errorf("Between returned %d samples, want 10", len(got))
+	}
+	// THE WINDOW AND THE REST OF ITS PAGE
+	if stub.reads > int(scanPageSize) {
+		t.Errorf("Between read %d keys, want at most %d", stub.reads, scanPageSize)
+	}
+}
+
+func TestBetweenPage(t *testing.T) {
+	stub := newStub(1000)
+	putSamples(t, stub, "h", 5, 10, 20, 30, 40)
+
+	got := []string{}
+	bookmark := ""
+	for pages := 0; ; pages++ {
+		if pages == 3 {
+			t.Fatalf("the pages did not end, bookmark %q", bookmark)
+		}
+		page, next, err := BetweenPage(stub, "sample", "h", 10, 40, 2, bookmark)
+		if err != nil {
+			t.Fatalf("BetweenPage(%q): %v", bookmark, err)
+		}
+		got = append(got, page...)
+		if next == "" {
+			break
+		}
+		bookmark = next
+	}
+	if want := []string{sample("h", 10), sample("h", 20), sample("h", 30)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+
+	outside, _ := Key(stub, "sample", "h", model.Timestamp{TimeSeconds: 5})
+	otherHost, _ := Key(stub, "sample", "h1", model.Timestamp{TimeSeconds: 20})
+	for _, bookmark := range []string{"x", outside, otherHost} {
+		if _, _, err := BetweenPage(stub, "sample", "h", 10, 40, 2, bookmark); err == nil {
+			t.Errorf("BetweenPage accepted the bookmark %q", bookmark)
+		}
+	}
+}
+
+func TestBetweenIndex(t *testing.T) {
+	stub := newStub(1000)
+	stub.MockTransactionStart("put")
+	// TWO ENTRIES OF THE SAME TIME ARE TOLD APART BY THEIR REF
+	for _, ref := range []string{"a", "b"} {
+		key, err := IndexKey(stub, "index", "x", model.Timestamp{TimeSeconds: 10}, ref)
+		if err != nil {
+			t.Fatalf("IndexKey: %v", err)
+		}
+		err = stub.PutState(key, []byte(ref))
+		if err != nil {
+			t.Fatalf("PutState: %v", err)
+		}
+	}
+	stub.MockTransactionEnd("put")
+
+	got, err := Between(stub, "index", "x", 0, 100)
+	if err != nil {
+		t.Fatalf("Between: %v", err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Between = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/invoke"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Field-level validation of the payloads posted by the collectors (resource stats and latency results).
+// Every rule that fails adds a violation, so a single report lists everything that has to be fixed
+
+// Largest difference allowed between the timestamp of a payload and the timestamp of the transaction
+const MaxClockSkew = 5 * time.Minute
+
+// Rules reported in the violations
+const (
+	RuleRequired   = "required"
+	RuleRange      = "range"
+	RuleMax        = "max"
+	RuleUnique     = "unique"
+	RuleMonotonic  = "monotonic"
+	RuleSkew       = "skew"
+	RuleConsistent = "consistent"
+	RuleRegistered = "registered"
+)
+
+type Violation struct {
+	Field   string `json:"field"` //JSON path of the field, e.g. diskStats[0].used
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type Report struct {
+	Valid      bool        `json:"valid"`
+	Violations []Violation `json:"violations"`
+}
+
+func NewReport() Report {
+	return Report{Valid: true, Violations: []Violation{}}
+}
+
+func (r *Report) Add(field string, rule string, format string, args ...interface{}) {
+	r.Valid = false
+	r.Violations = append(r.Violations, Violation{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// Index returns the path of the element i of a list, e.g. Index("diskStats", 0, "used") is diskStats[0].used
+func Index(list string, i int, name string) string {
+	path := fmt.Sprintf("%s[%d]", list, i)
+	if name != "" {
+		path += "." + name
+	}
+	return path
+}
+
+func (r *Report) Required(field string, value string) {
+	if value == "" {
+		r.Add(field, RuleRequired, "the field is required")
+	}
+}
+
+// Range checks min <= value <= max
+func (r *Report) Range(field string, value float64, min float64, max float64) {
+	if value < min || value > max {
+		r.Add(field, RuleRange, "%v is out of the range [%v, %v]", value, min, max)
+	}
+}
+
+// Max checks value <= max, maxField being the field holding max
+func (r *Report) Max(field string, value float64, maxField string, max float64) {
+	if value > max {
+		r.Add(field, RuleMax, "%v is greater than %s (%v)", value, maxField, max)
+	}
+}
+
+// Timestamp checks that the timestamp is set, that its seconds and nanoseconds agree and,
+// unless now is zero, that it is within MaxClockSkew of now (the transaction timestamp)
+func (r *Report) Timestamp(field string, timestamp model.Timestamp, now time.Time) {
+	if timestamp.TimeSeconds <= 0 && timestamp.TimeNano <= 0 {
+		r.Add(field, RuleRequired, "the timestamp is required")
+		return
+	}
+	if timestamp.TimeSeconds > 0 && timestamp.TimeNano > 0 && timestamp.TimeNano/1e9 != timestamp.TimeSeconds {
+		r.Add(field+".timeNano", RuleConsistent, "%d nanoseconds do not match %d seconds", timestamp.TimeNano, timestamp.TimeSeconds)
+	}
+	if now.IsZero() {
+		return
+	}
+	t := time.Unix(timestamp.TimeSeconds, 0)
+	if timestamp.TimeNano > 0 {
+		t = time.Unix(0, timestamp.TimeNano)
+	}
+	skew := t.Sub(now)
+	if skew > MaxClockSkew || skew < -MaxClockSkew {
+		r.Add(field, RuleSkew, "the timestamp is %v away from the transaction timestamp, at most %v is allowed", skew.Round(time.Second), MaxClockSkew)
+	}
+}
+
+// Registered checks that hostname identifies an inventory asset that was not decommissioned, querying GetAssetByHostname.
+// Errors other than NOT_FOUND are returned, the report can not be trusted then
+func (r *Report) Registered(stub shim.ChaincodeStubInterface, inventory invoke.Chaincode, field string, hostname string) error {
+	if hostname == "" {
+		return nil
+	}
+	var asset model.Asset
+	err := inventory.Query(stub, &asset, "GetAssetByHostname", hostname)
+	if err != nil {
+		if errs.CodeOf(err) != errs.NotFound {
+			return err
+		}
+		r.Add(field, RuleRegistered, "no inventory asset is registered with the hostname %s", hostname)
+		return nil
+	}
+	if asset.State == model.StateDecommissioned {
+		r.Add(field, RuleRegistered, "the inventory asset %s registered with the hostname %s is decommissioned", asset.ID, hostname)
+	}
+	return nil
+}
+
+// Err returns nil for a valid report, otherwise an INVALID_ARGUMENT error carrying the violations as JSON
+func (r Report) Err(subject string) error {
+	if r.Valid {
+		return nil
+	}
+	violations, _ := json.Marshal(r.Violations)
+	return errs.InvalidArgumentf("invalid %s, %d violations: %s", subject, len(r.Violations), violations)
+}
@@ -0,0 +1,108 @@
+package validation
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+)
+
+var now = time.Unix(1700000000, 0)
+
+func at(t time.Time) model.Timestamp {
+	return model.Timestamp{TimeLocal: t, TimeSeconds: t.Unix(), TimeNano: t.UnixNano()}
+}
+
+// check runs rule on a new report and compares its violations to want, a nil want being a valid report
+func check(t *testing.T, name string, rule func(r *Report), want []Violation) {
+	t.Helper()
+	report := NewReport()
+	rule(&report)
+	if report.Valid != (len(want) == 0) {
+		t.Errorf("%s: valid = %v, violations %v", name, report.Valid, report.Violations)
+	}
+	if len(report.Violations) != len(want) {
+		t.Fatalf("%s: violations = %v, want %v", name, report.Violations, want)
+	}
+	for i, violation := range report.Violations {
+		if violation.Field != want[i].Field || violation.Rule != want[i].Rule {
+			t.Errorf("%s: violation %d = %s %s, want %s %s", name, i, violation.Field, violation.Rule, want[i].Field, want[i].Rule)
+		}
+		if !strings.Contains(violation.Message, want[i].Message) {
+			t.Errorf("%s: message %q does not contain %q", name, violation.Message, want[i].Message)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule func(r *Report)
+		want []Violation
+	}{
+		{"required set", func(r *Report) { r.Required("source", "h") }, nil},
+		{"required empty", func(r *Report) { r.Required("source", "") }, []Violation{{"source", RuleRequired, "the field is required"}}},
+
+		{"range min", func(r *Report) { r.Range("memStats.used", 0, 0, 100) }, nil},
+		{"range max", func(r *Report) { r.Range("memStats.used", 100, 0, 100) }, nil},
+		{"range unbounded", func(r *Report) { r.Range("procStats.totalProcs", 1e12, 0, math.Inf(1)) }, nil},
+		{"range below", func(r *Report) { r.Range("memStats.used", -0.5, 0, 100) }, []Violation{{"memStats.used", RuleRange, "-0.5 is out of the range [0, 100]"}}},
+		{"range above", func(r *Report) { r.Range("memStats.used", 100.5, 0, 100) }, []Violation{{"memStats.used", RuleRange, "100.5 is out of the range [0, 100]"}}},
+
+		{"max equal", func(r *Report) { r.Max("memStats.available", 8, "memStats.total", 8) }, nil},
+		{"max above", func(r *Report) { r.Max("memStats.available", 9, "memStats.total", 8) }, []Violation{{"memStats.available", RuleMax, "9 is greater than memStats.total (8)"}}},
+
+		{"index", func(r *Report) { r.Required(Index("diskStats", 2, "used"), "") }, []Violation{{"diskStats[2].used", RuleRequired, ""}}},
+		{"index without name", func(r *Report) { r.Required(Index("cpuStats.coreUsage", 0, ""), "") }, []Violation{{"cpuStats.coreUsage[0]", RuleRequired, ""}}},
+
+		{"every violation is reported", func(r *Report) {
+			r.Required("source", "")
+			r.Range("memStats.used", 101, 0, 100)
+		}, []Violation{{"source", RuleRequired, ""}, {"memStats.used", RuleRange, ""}}},
+	}
+	for _, tt := range tests {
+		check(t, tt.name, tt.rule, tt.want)
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp model.Timestamp
+		now       time.Time
+		want      []Violation
+	}{
+		{"now", at(now), now, nil},
+		{"seconds only", model.Timestamp{TimeSeconds: now.Unix()}, now, nil},
+		{"nanoseconds only", model.Timestamp{TimeNano: now.UnixNano()}, now, nil},
+		{"no transaction timestamp", at(now.Add(-time.Hour)), time.Time{}, nil},
+		{"behind by MaxClockSkew", at(now.Add(-MaxClockSkew)), now, nil},
+		{"ahead by MaxClockSkew", at(now.Add(MaxClockSkew)), now, nil},
+
+		{"missing", model.Timestamp{}, now, []Violation{{"timestamp", RuleRequired, "the timestamp is required"}}},
+		{"behind by more than MaxClockSkew", at(now.Add(-MaxClockSkew - time.Second)), now, []Violation{{"timestamp", RuleSkew, "-5m1s away"}}},
+		{"ahead by more than MaxClockSkew", at(now.Add(MaxClockSkew + time.Second)), now, []Violation{{"timestamp", RuleSkew, "5m1s away"}}},
+		{"inconsistent", model.Timestamp{TimeSeconds: now.Unix(), TimeNano: now.Add(time.Minute).UnixNano()}, now, []Violation{{"timestamp.timeNano", RuleConsistent, "do not match"}}},
+	}
+	for _, tt := range tests {
+		check(t, tt.name, func(r *Report) { r.Timestamp("timestamp", tt.timestamp, tt.now) }, tt.want)
+	}
+}
+
+func TestErr(t *testing.T) {
+	report := NewReport()
+	if err := report.Err("stats"); err != nil {
+		t.Errorf("Err of a valid report = %v", err)
+	}
+	report.Required("host.hostname", "")
+	err := report.Err("stats")
+	if err == nil || errs.CodeOf(err) != errs.InvalidArgument {
+		t.Fatalf("Err = %v, want INVALID_ARGUMENT", err)
+	}
+	if !strings.Contains(err.Error(), `"field":"host.hostname"`) {
+		t.Errorf("Err = %v, want the violations as JSON", err)
+	}
+}
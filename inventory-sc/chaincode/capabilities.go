@@ -0,0 +1,101 @@
+package chaincode
+
+import (
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/mango"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// FindAssetsByCapabilities returns the assets in a placeable state matching every requirement (see model.CapabilityRequirements),
+// e.g. {"types": ["server"], "arch": ["arm64"], "minRamMB": 4096, "frameworks": ["pytorch"]}
+func (s *SmartContract) FindAssetsByCapabilities(ctx contractapi.TransactionContextInterface, requirementsJson string) ([]model.Asset, error) {
+	query, err := capabilitiesQuery(ctx, requirementsJson)
+	if err != nil {
+		return nil, err
+	}
+	return stringQuery(ctx, query.String())
+}
+
+func (s *SmartContract) FindAssetsByCapabilitiesPage(ctx contractapi.TransactionContextInterface, requirementsJson string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	query, err := capabilitiesQuery(ctx, requirementsJson)
+	if err != nil {
+		return model.AssetPage{}, err
+	}
+	return stringQueryPage(ctx, query.String(), pageSize, bookmark)
+}
+
+// capabilitiesQuery always selects on type and state, so the indexes of META-INF/statedb/couchdb/indexes can be used
+func capabilitiesQuery(ctx contractapi.TransactionContextInterface, requirementsJson string) (mango.Query, error) {
+	requirements, err := model.JsonToCapabilityRequirements(requirementsJson)
+	if err != nil {
+		return mango.Query{}, errs.InvalidArgumentf("invalid requirements: %v", err)
+	}
+
+	codes := []interface{}{}
+	if len(requirements.Types) == 0 {
+		assetTypes, err := getAssetTypes(ctx)
+		if err != nil {
+			return mango.Query{}, err
+		}
+		for _, assetType := range assetTypes {
+			codes = append(codes, assetType.Code)
+		}
+	}
+	for _, name := range requirements.Types {
+		assetType, err := readAssetType(ctx, name)
+		if err != nil {
+			return mango.Query{}, err
+		}
+		codes = append(codes, assetType.Code)
+	}
+
+	conditions := []mango.Condition{mango.In("type", codes...), placeableState()}
+	if len(requirements.Arch) > 0 {
+		conditions = append(conditions, mango.In("properties.arch", stringValues(requirements.Arch)...))
+	}
+	if requirements.MinCPUCores > 0 {
+		conditions = append(conditions, mango.Gte("properties.cpuCores", requirements.MinCPUCores))
+	}
+	if requirements.MinRAMMB > 0 {
+		conditions = append(conditions, mango.Gte("properties.ramMB", requirements.MinRAMMB))
+	}
+	if requirements.GPU {
+		conditions = append(conditions, mango.Eq("properties.gpu", 1))
+	}
+	if len(requirements.GPUModel) > 0 {
+		conditions = append(conditions, mango.In("properties.gpuModel", stringValues(requirements.GPUModel)...))
+	}
+	if requirements.MinGPUVRAMMB > 0 {
+		conditions = append(conditions, mango.Gte("properties.gpuVramMB", requirements.MinGPUVRAMMB))
+	}
+	if len(requirements.Accelerators) > 0 {
+		conditions = append(conditions, mango.All("properties.accelerators", stringValues(requirements.Accelerators)...))
+	}
+	if len(requirements.OS) > 0 {
+		conditions = append(conditions, mango.In("properties.os", stringValues(requirements.OS)...))
+	}
+	if len(requirements.ContainerRuntime) > 0 {
+		conditions = append(conditions, mango.In("properties.containerRuntime", stringValues(requirements.ContainerRuntime)...))
+	}
+	if len(requirements.Frameworks) > 0 {
+		conditions = append(conditions, mango.All("properties.frameworks", stringValues(requirements.Frameworks)...))
+	}
+	if len(requirements.Tags) > 0 {
+		conditions = append(conditions, mango.All("properties.tags", stringValues(requirements.Tags)...))
+	}
+	selector, err := parseLabelSelector(requirements.LabelSelector)
+	if err != nil {
+		return mango.Query{}, err
+	}
+	conditions = append(conditions, selector.Conditions("labels")...)
+	return mango.NewQuery(conditions...), nil
+}
+
+func stringValues(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
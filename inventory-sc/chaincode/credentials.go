@@ -0,0 +1,97 @@
+package chaincode
+
+import (
+	"encoding/json"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/access"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// GetAssetCredentials returns the host credentials of the asset, only for clients of the owner organization.
+// The query has to be evaluated on a peer of the owner organization, the only one storing the credentials
+func (s *SmartContract) GetAssetCredentials(ctx contractapi.TransactionContextInterface, assetKey string) (model.Credentials, error) {
+	asset, err := s.ReadAsset(ctx, assetKey)
+	if err != nil {
+		return model.Credentials{}, err
+	}
+	mspID, err := assertAssetOwner(ctx, asset)
+	if err != nil {
+		return model.Credentials{}, err
+	}
+	if asset.Properties.CredentialsHash == "" {
+		return model.Credentials{}, errs.NotFoundf("the Asset with key: %s has no credentials", assetKey)
+	}
+
+	credentialsJson, err := ctx.GetStub().GetPrivateData(access.OrgCollection(mspID), assetKey)
+	if err != nil {
+		return model.Credentials{}, errs.Internalf("failed to read from private data: %v", err)
+	}
+	if credentialsJson == nil {
+		return model.Credentials{}, errs.NotFoundf("the credentials of the Asset with key: %s are not stored in this peer", assetKey)
+	}
+	credentials, err := model.JsonToCredentials(string(credentialsJson))
+	if err != nil {
+		return model.Credentials{}, errs.Internalf("failed to decode the credentials of the Asset with key: %s. %v", assetKey, err)
+	}
+	return credentials, nil
+}
+
+// putCredentials stores the credentials sent in the transient map with their salt and returns their hash.
+// When no credentials were sent the current hash is kept
+func putCredentials(ctx contractapi.TransactionContextInterface, asset model.Asset, currentHash string) (string, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return "", errs.Internalf("failed to read the transient map: %v", err)
+	}
+	credentialsJson, found := transient[model.CredentialsTransientKey]
+	if !found {
+		return currentHash, nil
+	}
+	credentials, err := model.JsonToCredentials(string(credentialsJson))
+	if err != nil {
+		return "", errs.InvalidArgumentf("invalid credentials: %v", err)
+	}
+	credentials, err = model.SaltCredentials(credentials, transient[model.CredentialsSaltTransientKey])
+	if err != nil {
+		return "", errs.InvalidArgumentf("%v, sent in the transient map under the key %s", err, model.CredentialsSaltTransientKey)
+	}
+	mspID, err := assertAssetOwner(ctx, asset)
+	if err != nil {
+		return "", err
+	}
+
+	err = ctx.GetStub().PutPrivateData(access.OrgCollection(mspID), asset.ID, credentials.Bytes())
+	if err != nil {
+		return "", errs.Internalf("failed to put to private data: %v", err)
+	}
+	return model.HashCredentials(credentials), nil
+}
+
+// deleteCredentials removes the credentials of the asset from the private data of the owner organization,
+// also when an admin of another organization deletes the asset
+func deleteCredentials(ctx contractapi.TransactionContextInterface, asset model.Asset) error {
+	if asset.Properties.CredentialsHash == "" {
+		return nil
+	}
+	return errs.Wrap(errs.Internal, ctx.GetStub().DelPrivateData(access.OrgCollection(asset.Owner), asset.ID))
+}
+
+// assertNoPlaintextCredentials refuses assets posted with credentials in their properties,
+// transaction arguments are recorded in the blocks and readable by every channel member
+func assertNoPlaintextCredentials(assetJson string) error {
+	var posted struct {
+		Properties map[string]interface{} `json:"properties"`
+	}
+	err := json.Unmarshal([]byte(assetJson), &posted)
+	if err != nil {
+		return errs.InvalidArgumentf("invalid asset: %v", err)
+	}
+	for _, field := range []string{"hostUser", "hostPassword"} {
+		if _, found := posted.Properties[field]; found {
+			return errs.InvalidArgumentf("%s can not be sent in the asset, credentials are sent in the transient map (%s)", field, model.CredentialsTransientKey)
+		}
+	}
+	return nil
+}
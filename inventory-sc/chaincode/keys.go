@@ -0,0 +1,258 @@
+package chaincode
+
+import (
+	"time"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/clock"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RegisterAssetKey registers an SSH public key or X.509 certificate fingerprint for the asset, only for the owner organization or admins.
+// The fingerprint of SSH keys is computed from the public key
+func (s *SmartContract) RegisterAssetKey(ctx contractapi.TransactionContextInterface, assetID string, keyJson string) (model.AssetKey, error) {
+	asset, err := s.ReadAsset(ctx, assetID)
+	if err != nil {
+		return model.AssetKey{}, err
+	}
+	mspID, err := assertAssetModifier(ctx, asset)
+	if err != nil {
+		return model.AssetKey{}, err
+	}
+	now, err := clock.New(ctx.GetStub()).Now()
+	if err != nil {
+		return model.AssetKey{}, err
+	}
+
+	key, err := jsonToValidAssetKey(keyJson, assetID, now)
+	if err != nil {
+		return model.AssetKey{}, err
+	}
+	_, err = readAssetKey(ctx, assetID, key.Fingerprint)
+	if err == nil {
+		return model.AssetKey{}, errs.AlreadyExistsf("the key %s is already registered for the Asset with key: %s", key.Fingerprint, assetID)
+	}
+	if errs.CodeOf(err) != errs.NotFound {
+		return model.AssetKey{}, err
+	}
+
+	key.RegisteredBy = mspID
+	key.Timestamp = model.CreateTimestamp(now)
+	return key, putAssetKey(ctx, key)
+}
+
+// RotateAssetKey registers a new key and marks the active key with the given fingerprint as rotated
+func (s *SmartContract) RotateAssetKey(ctx contractapi.TransactionContextInterface, assetID string, fingerprint string, keyJson string) (model.AssetKey, error) {
+	key, err := s.RegisterAssetKey(ctx, assetID, keyJson)
+	if err != nil {
+		return model.AssetKey{}, err
+	}
+	previous, err := readAssetKey(ctx, assetID, fingerprint)
+	if err != nil {
+		return model.AssetKey{}, err
+	}
+	if previous.Status != model.KeyStatusActive {
+		return model.AssetKey{}, errs.InvalidArgumentf("the key %s of the Asset with key: %s is %s, only active keys can be rotated", fingerprint, assetID, previous.Status)
+	}
+
+	previous.Status = model.KeyStatusRotated
+	previous.ReplacedBy = key.Fingerprint
+	previous.Timestamp = key.Timestamp
+	return key, putAssetKey(ctx, previous)
+}
+
+// RevokeAssetKey revokes the key with the given fingerprint, revoked keys can not be registered again
+func (s *SmartContract) RevokeAssetKey(ctx contractapi.TransactionContextInterface, assetID string, fingerprint string, reason string) (model.AssetKey, error) {
+	asset, err := s.ReadAsset(ctx, assetID)
+	if err != nil {
+		return model.AssetKey{}, err
+	}
+	_, err = assertAssetModifier(ctx, asset)
+	if err != nil {
+		return model.AssetKey{}, err
+	}
+	key, err := readAssetKey(ctx, assetID, fingerprint)
+	if err != nil {
+		return model.AssetKey{}, err
+	}
+	if key.Status == model.KeyStatusRevoked {
+		return model.AssetKey{}, errs.InvalidArgumentf("the key %s of the Asset with key: %s is already revoked", fingerprint, assetID)
+	}
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return model.AssetKey{}, err
+	}
+
+	key.Status = model.KeyStatusRevoked
+	key.RevokedReason = reason
+	key.Timestamp = timestamp
+	return key, putAssetKey(ctx, key)
+}
+
+// GetAssetKeys returns every key registered for the asset, including rotated and revoked keys
+func (s *SmartContract) GetAssetKeys(ctx contractapi.TransactionContextInterface, assetID string) ([]model.AssetKey, error) {
+	return getAssetKeys(ctx, assetID)
+}
+
+func getAssetKeys(ctx contractapi.TransactionContextInterface, assetID string) ([]model.AssetKey, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(model.AssetKeyObjectType, []string{assetID})
+	if err != nil {
+		return nil, errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	keys := []model.AssetKey{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, errs.Internalf("failed to read query results: %v", err)
+		}
+		key, err := model.JsonToAssetKey(string(queryResponse.Value))
+		if err != nil {
+			return nil, errs.Internalf("failed to decode the keys of the Asset with key: %s. %v", assetID, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// GetValidAssetKeys returns the keys of the asset that are active and not expired at the transaction time,
+// filtered by usage (host, login, tls-server, tls-client). An empty usage returns every valid key
+func (s *SmartContract) GetValidAssetKeys(ctx contractapi.TransactionContextInterface, assetID string, usage string) ([]model.AssetKey, error) {
+	keys, err := getAssetKeys(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := clock.New(ctx.GetStub()).Now()
+	if err != nil {
+		return nil, err
+	}
+
+	validKeys := []model.AssetKey{}
+	for _, key := range keys {
+		if key.IsValid(now.Unix()) && (usage == "" || key.HasUsage(usage)) {
+			validKeys = append(validKeys, key)
+		}
+	}
+	return validKeys, nil
+}
+
+// deleteAssetKeys removes every key of the asset, used when the asset is deleted
+func deleteAssetKeys(ctx contractapi.TransactionContextInterface, assetID string) error {
+	keys, err := getAssetKeys(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		compositeKey, err := assetKeyCompositeKey(ctx, key.AssetID, key.Fingerprint)
+		if err != nil {
+			return err
+		}
+		err = ctx.GetStub().DelState(compositeKey)
+		if err != nil {
+			return errs.Internalf("failed to delete from world state: %v", err)
+		}
+	}
+	return nil
+}
+
+func readAssetKey(ctx contractapi.TransactionContextInterface, assetID string, fingerprint string) (model.AssetKey, error) {
+	compositeKey, err := assetKeyCompositeKey(ctx, assetID, fingerprint)
+	if err != nil {
+		return model.AssetKey{}, err
+	}
+	keyJson, err := ctx.GetStub().GetState(compositeKey)
+	if err != nil {
+		return model.AssetKey{}, errs.Internalf("failed to read from world state: %v", err)
+	}
+	if keyJson == nil {
+		return model.AssetKey{}, errs.NotFoundf("the key %s is not registered for the Asset with key: %s", fingerprint, assetID)
+	}
+	key, err := model.JsonToAssetKey(string(keyJson))
+	if err != nil {
+		return model.AssetKey{}, errs.Internalf("failed to decode the key %s of the Asset with key: %s. %v", fingerprint, assetID, err)
+	}
+	return key, nil
+}
+
+func putAssetKey(ctx contractapi.TransactionContextInterface, key model.AssetKey) error {
+	compositeKey, err := assetKeyCompositeKey(ctx, key.AssetID, key.Fingerprint)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(errs.Internal, ctx.GetStub().PutState(compositeKey, []byte(key.String())))
+}
+
+func assetKeyCompositeKey(ctx contractapi.TransactionContextInterface, assetID string, fingerprint string) (string, error) {
+	compositeKey, err := ctx.GetStub().CreateCompositeKey(model.AssetKeyObjectType, []string{assetID, fingerprint})
+	if err != nil {
+		return "", errs.InvalidArgumentf("invalid key: %v", err)
+	}
+	return compositeKey, nil
+}
+
+// jsonToValidAssetKey decodes a key posted by the client, fingerprint, status and history fields are set by the Smart Contract
+func jsonToValidAssetKey(keyJson string, assetID string, now time.Time) (model.AssetKey, error) {
+	posted, err := model.JsonToAssetKey(keyJson)
+	if err != nil {
+		return model.AssetKey{}, errs.InvalidArgumentf("invalid key: %v", err)
+	}
+
+	key := model.AssetKey{
+		AssetID:   assetID,
+		Kind:      posted.Kind,
+		Usage:     posted.Usage,
+		NotBefore: posted.NotBefore,
+		ExpiresAt: posted.ExpiresAt,
+		Status:    model.KeyStatusActive,
+	}
+	switch posted.Kind {
+	case model.KeyKindSSH:
+		key.PublicKey = posted.PublicKey
+		key.Fingerprint, err = model.SSHFingerprint(posted.PublicKey)
+	case model.KeyKindX509:
+		key.Fingerprint, err = model.NormalizeX509Fingerprint(posted.Fingerprint)
+	default:
+		return model.AssetKey{}, errs.InvalidArgumentf("unknown key kind %q, expected %s or %s", posted.Kind, model.KeyKindSSH, model.KeyKindX509)
+	}
+	if err != nil {
+		return model.AssetKey{}, errs.InvalidArgumentf("invalid key: %v", err)
+	}
+
+	if len(key.Usage) == 0 {
+		return model.AssetKey{}, errs.InvalidArgumentf("the key %s was posted without usage, expected any of %v", key.Fingerprint, model.KeyUsages)
+	}
+	for _, usage := range key.Usage {
+		if !contains(model.KeyUsages, usage) {
+			return model.AssetKey{}, errs.InvalidArgumentf("unknown usage %q for the key %s, expected any of %v", usage, key.Fingerprint, model.KeyUsages)
+		}
+	}
+	if key.NotBefore == 0 {
+		key.NotBefore = now.Unix()
+	}
+	if key.ExpiresAt != 0 && key.ExpiresAt <= key.NotBefore {
+		return model.AssetKey{}, errs.InvalidArgumentf("the key %s expires before it is valid", key.Fingerprint)
+	}
+	if key.ExpiresAt != 0 && key.ExpiresAt <= now.Unix() {
+		return model.AssetKey{}, errs.InvalidArgumentf("the key %s is already expired", key.Fingerprint)
+	}
+	return key, nil
+}
+
+func txTimestamp(ctx contractapi.TransactionContextInterface) (model.Timestamp, error) {
+	now, err := clock.New(ctx.GetStub()).Now()
+	if err != nil {
+		return model.Timestamp{}, err
+	}
+	return model.CreateTimestamp(now), nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
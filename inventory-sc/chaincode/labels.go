@@ -0,0 +1,95 @@
+package chaincode
+
+import (
+	"encoding/json"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/labels"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/mango"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AddLabels sets the labels of the asset (e.g. {"zone": "factory-2", "tier": "gpu"}), existing keys are overwritten.
+// Only for the owner organization or admins
+func (s *SmartContract) AddLabels(ctx contractapi.TransactionContextInterface, assetKey string, labelsJson string) (model.Asset, error) {
+	asset, err := s.ReadAsset(ctx, assetKey)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	_, err = assertAssetModifier(ctx, asset)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	added := map[string]string{}
+	err = json.Unmarshal([]byte(labelsJson), &added)
+	if err != nil {
+		return model.Asset{}, errs.InvalidArgumentf("invalid labels: %v", err)
+	}
+	err = labels.Validate(added)
+	if err != nil {
+		return model.Asset{}, errs.InvalidArgumentf("%v", err)
+	}
+
+	if asset.Labels == nil {
+		asset.Labels = map[string]string{}
+	}
+	for key, value := range added {
+		asset.Labels[key] = value
+	}
+	return asset, putAsset(ctx, asset)
+}
+
+// RemoveLabels deletes the labels with the given keys from the asset, missing keys are ignored.
+// Only for the owner organization or admins
+func (s *SmartContract) RemoveLabels(ctx contractapi.TransactionContextInterface, assetKey string, keys []string) (model.Asset, error) {
+	asset, err := s.ReadAsset(ctx, assetKey)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	_, err = assertAssetModifier(ctx, asset)
+	if err != nil {
+		return model.Asset{}, err
+	}
+
+	for _, key := range keys {
+		delete(asset.Labels, key)
+	}
+	return asset, putAsset(ctx, asset)
+}
+
+// GetAssetsByLabels returns the assets of every type in a placeable state matching the label selector,
+// e.g. "zone=factory-2,tier in (gpu,npu),!legacy"
+func (s *SmartContract) GetAssetsByLabels(ctx contractapi.TransactionContextInterface, labelSelector string) ([]model.Asset, error) {
+	query, err := labelsQuery(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	return stringQuery(ctx, query.String())
+}
+
+func (s *SmartContract) GetAssetsByLabelsPage(ctx contractapi.TransactionContextInterface, labelSelector string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	query, err := labelsQuery(labelSelector)
+	if err != nil {
+		return model.AssetPage{}, err
+	}
+	return stringQueryPage(ctx, query.String(), pageSize, bookmark)
+}
+
+func labelsQuery(labelSelector string) (mango.Query, error) {
+	selector, err := parseLabelSelector(labelSelector)
+	if err != nil {
+		return mango.Query{}, err
+	}
+	// THE TYPE CONDITION LETS COUCHDB USE indexTypeState
+	conditions := []mango.Condition{mango.Exists("type", true), placeableState()}
+	return mango.NewQuery(append(conditions, selector.Conditions("labels")...)...), nil
+}
+
+func parseLabelSelector(labelSelector string) (labels.Selector, error) {
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return nil, errs.InvalidArgumentf("invalid label selector: %v", err)
+	}
+	return selector, nil
+}
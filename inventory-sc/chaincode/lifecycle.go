@@ -0,0 +1,95 @@
+package chaincode
+
+import (
+	"sort"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/mango"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransitionAsset moves the asset to a new state (provisioning, active, draining, maintenance, faulty, decommissioned),
+// only for the owner organization or admins. Transitions not listed in model.StateTransitions are refused
+func (s *SmartContract) TransitionAsset(ctx contractapi.TransactionContextInterface, assetKey string, newState string, reason string) (model.Asset, error) {
+	asset, err := s.ReadAsset(ctx, assetKey)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	mspID, err := assertAssetModifier(ctx, asset)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	state, err := model.ParseAssetState(newState)
+	if err != nil {
+		return model.Asset{}, errs.InvalidArgumentf("%v", err)
+	}
+	if !model.CanTransition(asset.State, state) {
+		return model.Asset{}, errs.InvalidArgumentf("the Asset with key: %s can not transition from %s to %s", assetKey, model.StateName(asset.State), newState)
+	}
+
+	err = putStateTransition(ctx, assetKey, model.StateName(asset.State), newState, reason, mspID)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	asset.State = state
+	return asset, putAsset(ctx, asset)
+}
+
+// GetStateHistory returns every state transition of the asset, oldest first
+func (s *SmartContract) GetStateHistory(ctx contractapi.TransactionContextInterface, assetKey string) ([]model.StateTransition, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(model.StateTransitionObjectType, []string{assetKey})
+	if err != nil {
+		return nil, errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	transitions := []model.StateTransition{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, errs.Internalf("failed to read query results: %v", err)
+		}
+		transition, err := model.JsonToStateTransition(string(queryResponse.Value))
+		if err != nil {
+			return nil, errs.Internalf("failed to decode the state history of the Asset with key: %s. %v", assetKey, err)
+		}
+		transitions = append(transitions, transition)
+	}
+	// COMPOSITE KEYS ARE ORDERED BY TXID, NOT BY TIME
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].Timestamp.TimeNano < transitions[j].Timestamp.TimeNano
+	})
+	return transitions, nil
+}
+
+// placeableState matches the assets that can receive work, used by every asset query
+func placeableState() mango.Condition {
+	states := make([]interface{}, len(model.PlaceableStates))
+	for i, state := range model.PlaceableStates {
+		states[i] = state
+	}
+	return mango.In("state", states...)
+}
+
+func putStateTransition(ctx contractapi.TransactionContextInterface, assetKey string, from string, to string, reason string, submittedBy string) error {
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	txID := ctx.GetStub().GetTxID()
+	compositeKey, err := ctx.GetStub().CreateCompositeKey(model.StateTransitionObjectType, []string{assetKey, txID})
+	if err != nil {
+		return errs.InvalidArgumentf("invalid key: %v", err)
+	}
+	transition := model.StateTransition{
+		AssetID:     assetKey,
+		From:        from,
+		To:          to,
+		Reason:      reason,
+		SubmittedBy: submittedBy,
+		TxID:        txID,
+		Timestamp:   timestamp,
+	}
+	return errs.Wrap(errs.Internal, ctx.GetStub().PutState(compositeKey, []byte(transition.String())))
+}
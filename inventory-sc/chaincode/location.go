@@ -0,0 +1,206 @@
+package chaincode
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/geo"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// UpdatePosition moves the asset to new coordinates, e.g. a robot reporting its position.
+// Only the coordinates and geohash change, for the owner organization or admins
+func (s *SmartContract) UpdatePosition(ctx contractapi.TransactionContextInterface, assetKey string, lat float64, lon float64) (model.Location, error) {
+	asset, err := s.ReadAsset(ctx, assetKey)
+	if err != nil {
+		return model.Location{}, err
+	}
+	_, err = assertAssetModifier(ctx, asset)
+	if err != nil {
+		return model.Location{}, err
+	}
+	err = geo.ValidateCoordinates(lat, lon)
+	if err != nil {
+		return model.Location{}, errs.InvalidArgumentf("%v", err)
+	}
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return model.Location{}, err
+	}
+
+	moved := asset
+	moved.Location.Coordinates = &model.Coordinates{Lat: lat, Lon: lon, Timestamp: timestamp}
+	moved.Location.Geohash = geo.Encode(lat, lon, geo.MaxPrecision)
+	err = putLocationIndex(ctx, asset, moved)
+	if err != nil {
+		return model.Location{}, err
+	}
+	return moved.Location, putAsset(ctx, moved)
+}
+
+// GetAssetsByLocation returns the assets of a site, building or zone, in any state.
+// An empty building returns the whole site and an empty zone the whole building
+func (s *SmartContract) GetAssetsByLocation(ctx contractapi.TransactionContextInterface, site string, building string, zone string) ([]model.Asset, error) {
+	if site == "" || (building == "" && zone != "") {
+		return nil, errs.InvalidArgumentf("the location must be a site, a site and building, or a site, building and zone")
+	}
+	attributes := []string{site}
+	if building != "" {
+		attributes = append(attributes, building)
+		if zone != "" {
+			attributes = append(attributes, zone)
+		}
+	}
+	assetIDs, err := indexedAssetIDs(ctx, model.AssetSiteObjectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	assets := []model.Asset{}
+	for _, assetID := range assetIDs {
+		asset, err := s.ReadAsset(ctx, assetID)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, asset)
+	}
+	return assets, nil
+}
+
+// GetAssetsNear returns the assets, in any state, within radiusMeters of the point, nearest first
+func (s *SmartContract) GetAssetsNear(ctx contractapi.TransactionContextInterface, lat float64, lon float64, radiusMeters float64) ([]model.AssetDistance, error) {
+	err := geo.ValidateCoordinates(lat, lon)
+	if err != nil {
+		return nil, errs.InvalidArgumentf("%v", err)
+	}
+	if radiusMeters <= 0 || radiusMeters > geo.MaxRadiusMeters {
+		return nil, errs.InvalidArgumentf("the radius must be in (0, %d] meters", geo.MaxRadiusMeters)
+	}
+
+	found := []model.AssetDistance{}
+	for _, cell := range geo.Cover(lat, lon, radiusMeters) {
+		assetIDs, err := indexedAssetIDs(ctx, model.AssetGeoObjectType, strings.Split(cell, ""))
+		if err != nil {
+			return nil, err
+		}
+		for _, assetID := range assetIDs {
+			asset, err := s.ReadAsset(ctx, assetID)
+			if err != nil {
+				return nil, err
+			}
+			coordinates := asset.Location.Coordinates
+			if coordinates == nil {
+				continue
+			}
+			distance := geo.Distance(lat, lon, coordinates.Lat, coordinates.Lon)
+			if distance <= radiusMeters {
+				found = append(found, model.AssetDistance{Asset: asset, Distance: distance})
+			}
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].Distance < found[j].Distance
+	})
+	return found, nil
+}
+
+// validateLocation checks the hierarchy and coordinates of a posted asset and sets its geohash.
+// The position timestamp is kept unless the coordinates changed
+func validateLocation(ctx contractapi.TransactionContextInterface, asset *model.Asset, stored model.Asset) error {
+	location := &asset.Location
+	if (location.Building != "" && location.Site == "") || (location.Zone != "" && location.Building == "") {
+		return errs.InvalidArgumentf("the location of the Asset with key: %s must be a site, a site and building, or a site, building and zone", asset.ID)
+	}
+	location.Geohash = ""
+	if location.Coordinates == nil {
+		return nil
+	}
+	err := geo.ValidateCoordinates(location.Coordinates.Lat, location.Coordinates.Lon)
+	if err != nil {
+		return errs.InvalidArgumentf("%v", err)
+	}
+	location.Geohash = geo.Encode(location.Coordinates.Lat, location.Coordinates.Lon, geo.MaxPrecision)
+
+	previous := stored.Location.Coordinates
+	if previous != nil && previous.Lat == location.Coordinates.Lat && previous.Lon == location.Coordinates.Lon {
+		location.Coordinates.Timestamp = previous.Timestamp
+		return nil
+	}
+	location.Coordinates.Timestamp, err = txTimestamp(ctx)
+	return err
+}
+
+// putLocationIndex replaces the site and geohash keys of previous with the ones of current.
+// An empty current (deleted asset) only removes the keys
+func putLocationIndex(ctx contractapi.TransactionContextInterface, previous model.Asset, current model.Asset) error {
+	previousKeys, err := locationKeys(ctx, previous)
+	if err != nil {
+		return err
+	}
+	currentKeys, err := locationKeys(ctx, current)
+	if err != nil {
+		return err
+	}
+	for _, key := range previousKeys {
+		if !contains(currentKeys, key) {
+			err = ctx.GetStub().DelState(key)
+			if err != nil {
+				return errs.Internalf("failed to delete from world state: %v", err)
+			}
+		}
+	}
+	for _, key := range currentKeys {
+		if !contains(previousKeys, key) {
+			// THE VALUE CAN NOT BE EMPTY, FABRIC TREATS EMPTY WRITES AS DELETES
+			err = ctx.GetStub().PutState(key, []byte(current.ID))
+			if err != nil {
+				return errs.Internalf("failed to put to world state: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func locationKeys(ctx contractapi.TransactionContextInterface, asset model.Asset) ([]string, error) {
+	keys := []string{}
+	if asset.ID == "" {
+		return keys, nil
+	}
+	location := asset.Location
+	if location.Site != "" {
+		key, err := ctx.GetStub().CreateCompositeKey(model.AssetSiteObjectType, []string{location.Site, location.Building, location.Zone, asset.ID})
+		if err != nil {
+			return nil, errs.InvalidArgumentf("invalid location: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	if location.Geohash != "" {
+		key, err := ctx.GetStub().CreateCompositeKey(model.AssetGeoObjectType, append(strings.Split(location.Geohash, ""), asset.ID))
+		if err != nil {
+			return nil, errs.InvalidArgumentf("invalid location: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// indexedAssetIDs returns the asset IDs stored under the partial composite key
+func indexedAssetIDs(ctx contractapi.TransactionContextInterface, objectType string, attributes []string) ([]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	assetIDs := []string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, errs.Internalf("failed to read query results: %v", err)
+		}
+		assetIDs = append(assetIDs, string(queryResponse.Value))
+	}
+	return assetIDs, nil
+}
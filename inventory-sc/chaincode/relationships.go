@@ -0,0 +1,268 @@
+package chaincode
+
+import (
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// AddRelationship links two assets, e.g. (sensor-1, attachedTo, gateway-1), only for the owner organization of fromKey or admins
+func (s *SmartContract) AddRelationship(ctx contractapi.TransactionContextInterface, fromKey string, relationType string, toKey string) (model.Relationship, error) {
+	if !contains(model.RelationTypes, relationType) {
+		return model.Relationship{}, errs.InvalidArgumentf("unknown relationship type %s, expected any of %v", relationType, model.RelationTypes)
+	}
+	if fromKey == toKey {
+		return model.Relationship{}, errs.InvalidArgumentf("the Asset with key: %s can not be related to itself", fromKey)
+	}
+	from, err := s.ReadAsset(ctx, fromKey)
+	if err != nil {
+		return model.Relationship{}, err
+	}
+	_, err = s.ReadAsset(ctx, toKey)
+	if err != nil {
+		return model.Relationship{}, err
+	}
+	mspID, err := assertAssetModifier(ctx, from)
+	if err != nil {
+		return model.Relationship{}, err
+	}
+
+	existing, found, err := readRelationship(ctx, fromKey, relationType, toKey)
+	if err != nil {
+		return model.Relationship{}, err
+	}
+	if found {
+		return model.Relationship{}, errs.AlreadyExistsf("the relationship (%s, %s, %s) already exists", existing.From, existing.Type, existing.To)
+	}
+
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return model.Relationship{}, err
+	}
+	relationship := model.Relationship{
+		From:      fromKey,
+		Type:      relationType,
+		To:        toKey,
+		CreatedBy: mspID,
+		TxID:      ctx.GetStub().GetTxID(),
+		Timestamp: timestamp,
+	}
+	keys, err := relationshipKeys(ctx, relationship)
+	if err != nil {
+		return model.Relationship{}, err
+	}
+	for _, key := range keys {
+		err = ctx.GetStub().PutState(key, []byte(relationship.String()))
+		if err != nil {
+			return model.Relationship{}, errs.Internalf("failed to put to world state: %v", err)
+		}
+	}
+	return relationship, nil
+}
+
+// RemoveRelationship unlinks two assets, for the owner organization of either asset or admins
+func (s *SmartContract) RemoveRelationship(ctx contractapi.TransactionContextInterface, fromKey string, relationType string, toKey string) error {
+	relationship, found, err := readRelationship(ctx, fromKey, relationType, toKey)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errs.NotFoundf("the relationship (%s, %s, %s) does not exist", fromKey, relationType, toKey)
+	}
+	from, err := s.ReadAsset(ctx, relationship.From)
+	if err != nil {
+		return err
+	}
+	_, err = assertAssetModifier(ctx, from)
+	if err != nil {
+		to, readErr := s.ReadAsset(ctx, relationship.To)
+		if readErr != nil {
+			return readErr
+		}
+		_, err = assertAssetModifier(ctx, to)
+		if err != nil {
+			return err
+		}
+	}
+	return deleteRelationships(ctx, []model.Relationship{relationship})
+}
+
+// GetNeighbours returns the relationships of the asset. relationType "" returns every type
+// and direction is one of out (edges from the asset), in (edges to the asset) or both ("")
+func (s *SmartContract) GetNeighbours(ctx contractapi.TransactionContextInterface, assetKey string, relationType string, direction string) ([]model.Relationship, error) {
+	direction, err := validateRelationFilter(relationType, direction)
+	if err != nil {
+		return nil, err
+	}
+	return readRelationships(ctx, assetKey, relationType, direction)
+}
+
+// GetReachableAssets returns every asset reachable from assetKey following relationType edges in direction, nearest first.
+// typeName "" returns assets of every type, e.g. ("gateway-1", "attachedTo", "in", "sensor") returns all the sensors reachable via gateway-1
+func (s *SmartContract) GetReachableAssets(ctx contractapi.TransactionContextInterface, assetKey string, relationType string, direction string, typeName string) ([]model.Asset, error) {
+	direction, err := validateRelationFilter(relationType, direction)
+	if err != nil {
+		return nil, err
+	}
+	typeCode := -1
+	if typeName != "" {
+		assetType, err := readAssetType(ctx, typeName)
+		if err != nil {
+			return nil, err
+		}
+		typeCode = assetType.Code
+	}
+	_, err = s.ReadAsset(ctx, assetKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// BREADTH FIRST, EVERY ASSET IS VISITED ONCE SO CYCLES END THE WALK
+	visited := map[string]bool{assetKey: true}
+	queue := []string{assetKey}
+	assets := []model.Asset{}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		relationships, err := readRelationships(ctx, current, relationType, direction)
+		if err != nil {
+			return nil, err
+		}
+		for _, relationship := range relationships {
+			neighbour := relationship.Neighbour(current)
+			if visited[neighbour] {
+				continue
+			}
+			visited[neighbour] = true
+			queue = append(queue, neighbour)
+			asset, err := s.ReadAsset(ctx, neighbour)
+			if err != nil {
+				return nil, err
+			}
+			if typeCode < 0 || asset.Type == typeCode {
+				assets = append(assets, asset)
+			}
+		}
+	}
+	return assets, nil
+}
+
+func validateRelationFilter(relationType string, direction string) (string, error) {
+	if relationType != "" && !contains(model.RelationTypes, relationType) {
+		return "", errs.InvalidArgumentf("unknown relationship type %s, expected any of %v", relationType, model.RelationTypes)
+	}
+	switch direction {
+	case "":
+		return model.DirectionBoth, nil
+	case model.DirectionOut, model.DirectionIn, model.DirectionBoth:
+		return direction, nil
+	}
+	return "", errs.InvalidArgumentf("unknown direction %s, expected any of %v", direction, []string{model.DirectionOut, model.DirectionIn, model.DirectionBoth})
+}
+
+// handleRelationships applies the option of DeleteAssetWithRelations (refuse or cascade) to the edges of the deleted asset
+func handleRelationships(ctx contractapi.TransactionContextInterface, assetKey string, relationsMode string) error {
+	relationships, err := readRelationships(ctx, assetKey, "", model.DirectionBoth)
+	if err != nil {
+		return err
+	}
+	if len(relationships) > 0 && relationsMode != model.RelationsCascade {
+		return errs.InvalidArgumentf("the Asset with key: %s has %d relationships, remove them first or delete it with DeleteAssetWithRelations and the option %s", assetKey, len(relationships), model.RelationsCascade)
+	}
+	return deleteRelationships(ctx, relationships)
+}
+
+// readRelationship returns the edge (from, type, to), pairedWith edges are also found from the other end
+func readRelationship(ctx contractapi.TransactionContextInterface, fromKey string, relationType string, toKey string) (model.Relationship, bool, error) {
+	attributes := [][]string{{fromKey, relationType, toKey}}
+	if relationType == model.RelationPairedWith {
+		attributes = append(attributes, []string{toKey, relationType, fromKey})
+	}
+	for _, attribute := range attributes {
+		compositeKey, err := ctx.GetStub().CreateCompositeKey(model.RelationshipObjectType, attribute)
+		if err != nil {
+			return model.Relationship{}, false, errs.InvalidArgumentf("invalid key: %v", err)
+		}
+		relationshipJson, err := ctx.GetStub().GetState(compositeKey)
+		if err != nil {
+			return model.Relationship{}, false, errs.Internalf("failed to read from world state: %v", err)
+		}
+		if relationshipJson == nil {
+			continue
+		}
+		relationship, err := model.JsonToRelationship(string(relationshipJson))
+		if err != nil {
+			return model.Relationship{}, false, errs.Internalf("failed to decode the relationship with key: %s. %v", compositeKey, err)
+		}
+		return relationship, true, nil
+	}
+	return model.Relationship{}, false, nil
+}
+
+// readRelationships returns the edges of the asset in direction. pairedWith edges are symmetric,
+// so they are returned in both directions whatever the direction asked
+func readRelationships(ctx contractapi.TransactionContextInterface, assetKey string, relationType string, direction string) ([]model.Relationship, error) {
+	relationships := []model.Relationship{}
+	for _, objectType := range []string{model.RelationshipObjectType, model.RelationshipInObjectType} {
+		sameDirection := direction == model.DirectionBoth ||
+			(direction == model.DirectionOut && objectType == model.RelationshipObjectType) ||
+			(direction == model.DirectionIn && objectType == model.RelationshipInObjectType)
+		if !sameDirection && relationType != "" && relationType != model.RelationPairedWith {
+			continue
+		}
+		attributes := []string{assetKey}
+		if relationType != "" {
+			attributes = append(attributes, relationType)
+		}
+		resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(objectType, attributes)
+		if err != nil {
+			return nil, errs.Internalf("failed to read from world state: %v", err)
+		}
+		for resultsIterator.HasNext() {
+			queryResponse, err := resultsIterator.Next()
+			if err != nil {
+				resultsIterator.Close()
+				return nil, errs.Internalf("failed to read query results: %v", err)
+			}
+			relationship, err := model.JsonToRelationship(string(queryResponse.Value))
+			if err != nil {
+				resultsIterator.Close()
+				return nil, errs.Internalf("failed to decode the relationship with key: %s. %v", queryResponse.Key, err)
+			}
+			if sameDirection || relationship.Type == model.RelationPairedWith {
+				relationships = append(relationships, relationship)
+			}
+		}
+		resultsIterator.Close()
+	}
+	return relationships, nil
+}
+
+func deleteRelationships(ctx contractapi.TransactionContextInterface, relationships []model.Relationship) error {
+	for _, relationship := range relationships {
+		keys, err := relationshipKeys(ctx, relationship)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			err = ctx.GetStub().DelState(key)
+			if err != nil {
+				return errs.Internalf("failed to delete from world state: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// relationshipKeys returns the keys of the edge from both ends
+func relationshipKeys(ctx contractapi.TransactionContextInterface, relationship model.Relationship) ([]string, error) {
+	outKey, err := ctx.GetStub().CreateCompositeKey(model.RelationshipObjectType, []string{relationship.From, relationship.Type, relationship.To})
+	if err != nil {
+		return nil, errs.InvalidArgumentf("invalid key: %v", err)
+	}
+	inKey, err := ctx.GetStub().CreateCompositeKey(model.RelationshipInObjectType, []string{relationship.To, relationship.Type, relationship.From})
+	if err != nil {
+		return nil, errs.InvalidArgumentf("invalid key: %v", err)
+	}
+	return []string{outKey, inKey}, nil
+}
@@ -2,9 +2,12 @@ package chaincode
 
 import (
 	"encoding/json"
-	"fmt"
 
-	"github.com/dmonteroh/distributed-resources-smartcontract/inventory-sc/internal"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/access"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/labels"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/mango"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
@@ -14,9 +17,9 @@ type SmartContract struct {
 	contractapi.Contract
 }
 
-// InitLedger adds a base set of assets to the ledger
+// InitLedger adds a base set of assets and the default asset types to the ledger
 func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
-	assets := []internal.Asset{
+	assets := []model.Asset{
 		// {ID: "localhost", Name: "localhost-laptop", Owner: "Org1", Type: 0, State: 1, Properties: map[string]string{"GPU": "true"}},
 		// {ID: "172.26.45.114", Name: "172.26.45.114-jetson", Owner: "Org1", Type: 0, State: 1, Properties: map[string]string{"GPU": "true"}},
 	}
@@ -25,7 +28,13 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 		tmpJson := []byte(asset.String())
 		err := ctx.GetStub().PutState(asset.ID, tmpJson)
 		if err != nil {
-			return fmt.Errorf("failed to put to world state. %v", err)
+			return errs.Internalf("failed to put to world state. %v", err)
+		}
+	}
+	for _, assetType := range model.DefaultAssetTypes {
+		err := putAssetType(ctx, assetType)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -33,19 +42,19 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 }
 
 // ReadAsset returns the asset stored in the world state with given id.
-func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, assetKey string) (internal.Asset, error) {
+func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, assetKey string) (model.Asset, error) {
 	statJSON, err := ctx.GetStub().GetState(assetKey)
 	if err != nil {
-		return internal.Asset{}, fmt.Errorf("failed to read from world state: %v", err)
+		return model.Asset{}, errs.Internalf("failed to read from world state: %v", err)
 	}
 	if statJSON == nil {
-		return internal.Asset{}, fmt.Errorf("the Asset with key: %s does not exist", assetKey)
+		return model.Asset{}, errs.NotFoundf("the Asset with key: %s does not exist", assetKey)
 	}
 
-	var asset internal.Asset
+	var asset model.Asset
 	err = json.Unmarshal(statJSON, &asset)
 	if err != nil {
-		return internal.Asset{}, err
+		return model.Asset{}, errs.Internalf("failed to decode the Asset with key: %s. %v", assetKey, err)
 	}
 
 	return asset, nil
@@ -53,7 +62,11 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, a
 
 // CreateAsset issues a new asset to the world state with given details.
 func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, assetJson string) error {
-	asset, err := internal.JsonToAsset(assetJson)
+	asset, err := jsonToValidAsset(assetJson)
+	if err != nil {
+		return err
+	}
+	err = assertAllowedProperties(ctx, assetJson, asset)
 	if err != nil {
 		return err
 	}
@@ -62,156 +75,367 @@ func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface,
 		return err
 	}
 	if exists {
-		return fmt.Errorf("the Asset with key: %s already exists", asset.ID)
+		return errs.AlreadyExistsf("the Asset with key: %s already exists", asset.ID)
+	}
+	if !model.IsInitialState(asset.State) {
+		return errs.InvalidArgumentf("the Asset with key: %s can not be created as %s", asset.ID, model.StateName(asset.State))
+	}
+	// THE OWNER IS THE ORGANIZATION OF THE SUBMITTING CLIENT
+	asset.Owner, err = access.MSPID(ctx.GetClientIdentity())
+	if err != nil {
+		return err
+	}
+	asset.Properties.CredentialsHash, err = putCredentials(ctx, asset, "")
+	if err != nil {
+		return err
+	}
+	err = putOwnershipRecord(ctx, asset.ID, model.OwnershipCreated, "", asset.Owner, "", asset.Owner)
+	if err != nil {
+		return err
+	}
+	err = putStateTransition(ctx, asset.ID, "", model.StateName(asset.State), "created", asset.Owner)
+	if err != nil {
+		return err
+	}
+	err = validateLocation(ctx, &asset, model.Asset{})
+	if err != nil {
+		return err
+	}
+	err = putLocationIndex(ctx, model.Asset{}, asset)
+	if err != nil {
+		return err
 	}
 
-	// RUN VALIDATIONS
-	validJson := []byte(asset.String())
-
-	return ctx.GetStub().PutState(asset.ID, validJson)
+	return putAsset(ctx, asset)
 }
 
 // UpdateAsset updates an existing asset in the world state with provided parameters.
 func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, assetJson string) error {
-	asset, err := internal.JsonToAsset(assetJson)
+	asset, err := jsonToValidAsset(assetJson)
 	if err != nil {
 		return err
 	}
-	exists, err := s.AssetExists(ctx, asset.ID)
+	err = assertAllowedProperties(ctx, assetJson, asset)
 	if err != nil {
 		return err
 	}
-	if !exists {
-		return fmt.Errorf("the Asset with key: %s does not exist", asset.ID)
+	stored, err := s.ReadAsset(ctx, asset.ID)
+	if err != nil {
+		return err
+	}
+	_, err = assertAssetModifier(ctx, stored)
+	if err != nil {
+		return err
+	}
+	// THE OWNER CAN ONLY BE CHANGED WITH A TRANSFER, THE STATE WITH A TRANSITION AND THE LABELS WITH ADD/REMOVELABELS
+	asset.Owner = stored.Owner
+	asset.PendingOwner = stored.PendingOwner
+	asset.State = stored.State
+	asset.Labels = stored.Labels
+	// CREDENTIALS ARE KEPT UNLESS NEW ONES ARE SENT IN THE TRANSIENT MAP
+	asset.Properties.CredentialsHash, err = putCredentials(ctx, asset, stored.Properties.CredentialsHash)
+	if err != nil {
+		return err
+	}
+	err = validateLocation(ctx, &asset, stored)
+	if err != nil {
+		return err
+	}
+	err = putLocationIndex(ctx, stored, asset)
+	if err != nil {
+		return err
 	}
 
-	// RUN VALIDATIONS
-	validJson := []byte(asset.String())
-
-	return ctx.GetStub().PutState(asset.ID, validJson)
+	return putAsset(ctx, asset)
 }
 
 // DeleteAsset deletes an given asset from the world state.
+// Assets that still have relationships are refused, see DeleteAssetWithRelations
 func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface, assetKey string) error {
-	exists, err := s.AssetExists(ctx, assetKey)
+	return s.deleteAsset(ctx, assetKey, model.RelationsRefuse)
+}
+
+// DeleteAssetWithRelations deletes the asset, relationsMode decides what happens to its relationships: refuse or cascade
+func (s *SmartContract) DeleteAssetWithRelations(ctx contractapi.TransactionContextInterface, assetKey string, relationsMode string) error {
+	if relationsMode != model.RelationsRefuse && relationsMode != model.RelationsCascade {
+		return errs.InvalidArgumentf("unknown relationships option %q, expected %s or %s", relationsMode, model.RelationsRefuse, model.RelationsCascade)
+	}
+	return s.deleteAsset(ctx, assetKey, relationsMode)
+}
+
+func (s *SmartContract) deleteAsset(ctx contractapi.TransactionContextInterface, assetKey string, relationsMode string) error {
+	asset, err := s.ReadAsset(ctx, assetKey)
 	if err != nil {
 		return err
 	}
-	if !exists {
-		return fmt.Errorf("the Asset with key: %s does not exist", assetKey)
+	mspID, err := assertAssetModifier(ctx, asset)
+	if err != nil {
+		return err
+	}
+	err = handleRelationships(ctx, assetKey, relationsMode)
+	if err != nil {
+		return err
+	}
+	err = deleteCredentials(ctx, asset)
+	if err != nil {
+		return err
+	}
+	err = deleteAssetKeys(ctx, assetKey)
+	if err != nil {
+		return err
+	}
+	err = putLocationIndex(ctx, asset, model.Asset{})
+	if err != nil {
+		return err
+	}
+	err = putOwnershipRecord(ctx, assetKey, model.OwnershipDeleted, asset.Owner, "", "", mspID)
+	if err != nil {
+		return err
 	}
 
-	return ctx.GetStub().DelState(assetKey)
+	return errs.Wrap(errs.Internal, ctx.GetStub().DelState(assetKey))
 }
 
 // AssetExists returns true when asset with given ID exists in world state
 func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface, assetKey string) (bool, error) {
 	asset, err := ctx.GetStub().GetState(assetKey)
 	if err != nil {
-		return false, fmt.Errorf("failed to read from world state: %v", err)
+		return false, errs.Internalf("failed to read from world state: %v", err)
 	}
 
 	return asset != nil, nil
 }
 
-// CURRENTLY IN TO-DO (NO OWNERSHIP REQUIRED ATM)
-// TransferAsset updates the owner field of asset with given id in world state.
-// func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, statIP string, newStatIP string) (string, error) {
-// 	statObject, err := s.ReadAsset(ctx, statIP)
-// 	if err != nil {
-// 		return "", err
-// 	}
+// GetAssetByHostname returns the asset, in any state, the collectors identify as hostname (see model.Asset.CollectorHostname)
+func (s *SmartContract) GetAssetByHostname(ctx contractapi.TransactionContextInterface, hostname string) (model.Asset, error) {
+	if hostname == "" {
+		return model.Asset{}, errs.InvalidArgumentf("the hostname is required")
+	}
+	assets, err := stringQuery(ctx, mango.NewQuery(mango.Eq("properties.hostname", hostname)).WithLimit(1).String())
+	if err != nil {
+		return model.Asset{}, err
+	}
+	if len(assets) > 0 {
+		return assets[0], nil
+	}
+	asset, err := s.ReadAsset(ctx, hostname)
+	if err != nil && errs.CodeOf(err) != errs.NotFound {
+		return model.Asset{}, err
+	}
+	if err == nil && asset.CollectorHostname() == hostname {
+		return asset, nil
+	}
+	return model.Asset{}, errs.NotFoundf("no Asset is registered with the hostname %s", hostname)
+}
 
-// 	statObject.ID = newStatIP
+func putAsset(ctx contractapi.TransactionContextInterface, asset model.Asset) error {
+	return errs.Wrap(errs.Internal, ctx.GetStub().PutState(asset.ID, []byte(asset.String())))
+}
 
-// 	return statObject.String(), ctx.GetStub().PutState(newStatIP, []byte(statObject.String()))
-// }
+// assertAssetOwner returns the MSP ID of the client when its organization owns the asset
+func assertAssetOwner(ctx contractapi.TransactionContextInterface, asset model.Asset) (string, error) {
+	mspID, err := access.MSPID(ctx.GetClientIdentity())
+	if err != nil {
+		return "", err
+	}
+	if mspID != asset.Owner {
+		return "", errs.Unauthorizedf("the organization %s is not the owner (%s) of the Asset with key: %s", mspID, asset.Owner, asset.ID)
+	}
+	return mspID, nil
+}
+
+// assertAssetModifier returns the MSP ID of the client when its organization owns the asset,
+// or when the client certificate carries the admin attribute
+func assertAssetModifier(ctx contractapi.TransactionContextInterface, asset model.Asset) (string, error) {
+	mspID, err := access.MSPID(ctx.GetClientIdentity())
+	if err != nil {
+		return "", err
+	}
+	if mspID == asset.Owner {
+		return mspID, nil
+	}
+	admin, err := access.IsAdmin(ctx.GetClientIdentity())
+	if err != nil {
+		return "", err
+	}
+	if !admin {
+		return "", errs.Unauthorizedf("the organization %s is not the owner (%s) of the Asset with key: %s and the client does not have the attribute %s=true", mspID, asset.Owner, asset.ID, access.AdminAttribute)
+	}
+	return mspID, nil
+}
+
+// jsonToValidAsset decodes an asset posted by the client, the owner, pending transfer, schema version and credentials hash are always set by the Smart Contract
+func jsonToValidAsset(assetJson string) (model.Asset, error) {
+	err := assertNoPlaintextCredentials(assetJson)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	asset, err := model.JsonToAsset(assetJson)
+	if err != nil {
+		return model.Asset{}, errs.InvalidArgumentf("invalid asset: %v", err)
+	}
+	if asset.ID == "" {
+		return model.Asset{}, errs.InvalidArgumentf("asset was posted without ID, ignored")
+	}
+	err = labels.Validate(asset.Labels)
+	if err != nil {
+		return model.Asset{}, errs.InvalidArgumentf("%v", err)
+	}
+	asset.Properties.CredentialsHash = ""
+	asset.Properties.SchemaVersion = model.PropertiesSchemaVersion
+	asset.PendingOwner = ""
+	return asset, nil
+}
 
 // GetAllAssets returns all assets found in world state
-func (s *SmartContract) GetAllAssets(ctx contractapi.TransactionContextInterface) ([]internal.Asset, error) {
+func (s *SmartContract) GetAllAssets(ctx contractapi.TransactionContextInterface) ([]model.Asset, error) {
 	// range query with empty string for startKey and endKey does an
 	// open-ended query of all assets in the chaincode namespace.
 	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
 	if err != nil {
-		return nil, err
+		return nil, errs.Internalf("failed to read from world state: %v", err)
 	}
 	defer resultsIterator.Close()
 	return iteratorSlicer(resultsIterator)
 }
 
+// GetAllAssetsPage returns a page of the assets found in world state, an empty bookmark starts from the first asset
+func (s *SmartContract) GetAllAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (model.AssetPage, error) {
+	if err := mango.ValidatePageSize(pageSize); err != nil {
+		return model.AssetPage{}, err
+	}
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", pageSize, bookmark)
+	if err != nil {
+		return model.AssetPage{}, errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+	assets, err := iteratorSlicer(resultsIterator)
+	if err != nil {
+		return model.AssetPage{}, err
+	}
+	return model.CreateAssetPage(assets, metadata.FetchedRecordsCount, metadata.Bookmark), nil
+}
+
 // https://stackoverflow.com/questions/66685696/couchdb-mango-query-match-any-key-with-array-item
 // GENERATE VIEW TO BETTER SEARCH PROPERTIES INSIDE INVENTORY ASSETS
 
-func (s *SmartContract) GetServerAssets(ctx contractapi.TransactionContextInterface) ([]internal.Asset, error) {
-	assetQuery := `{"selector":{"type":0,"state":1}}`
-	return stringQuery(ctx, assetQuery)
+// -- ASSET QUERIES
+// Compatibility wrappers of GetAssetsByType for the default types, only assets in a placeable state are returned
+var gpuFilter = map[string]interface{}{"gpu": 1}
+
+func (s *SmartContract) GetServerAssets(ctx contractapi.TransactionContextInterface) ([]model.Asset, error) {
+	return stringQuery(ctx, assetsByTypeQuery([]int{model.TypeServer}, nil, nil, nil).String())
+}
+
+func (s *SmartContract) GetServerGPUAssets(ctx contractapi.TransactionContextInterface) ([]model.Asset, error) {
+	return stringQuery(ctx, assetsByTypeQuery([]int{model.TypeServer}, nil, gpuFilter, nil).String())
+}
+
+func (s *SmartContract) GetServerAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]model.Asset, error) {
+	return stringQuery(ctx, assetsByTypeQuery([]int{model.TypeServer}, []string{excludeId}, nil, nil).String())
+}
+
+func (s *SmartContract) GetRobotAssets(ctx contractapi.TransactionContextInterface) ([]model.Asset, error) {
+	return stringQuery(ctx, assetsByTypeQuery([]int{model.TypeRobot}, nil, nil, nil).String())
+}
+
+func (s *SmartContract) GetRobotAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]model.Asset, error) {
+	return stringQuery(ctx, assetsByTypeQuery([]int{model.TypeRobot}, []string{excludeId}, nil, nil).String())
+}
+
+func (s *SmartContract) GetSensorAssets(ctx contractapi.TransactionContextInterface) ([]model.Asset, error) {
+	return stringQuery(ctx, assetsByTypeQuery([]int{model.TypeSensor}, nil, nil, nil).String())
+}
+
+func (s *SmartContract) GetSensorAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]model.Asset, error) {
+	return stringQuery(ctx, assetsByTypeQuery([]int{model.TypeSensor}, []string{excludeId}, nil, nil).String())
+}
+
+func (s *SmartContract) GetSensorAndRobotAssets(ctx contractapi.TransactionContextInterface) ([]model.Asset, error) {
+	return stringQuery(ctx, assetsByTypeQuery([]int{model.TypeRobot, model.TypeSensor}, nil, nil, nil).String())
 }
 
-func (s *SmartContract) GetServerGPUAssets(ctx contractapi.TransactionContextInterface) ([]internal.Asset, error) {
-	assetQuery := `{"selector":{"type":0,"state":1,"properties.gpu":1}}`
-	return stringQuery(ctx, assetQuery)
+func (s *SmartContract) GetSensorAndRobotAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]model.Asset, error) {
+	return stringQuery(ctx, assetsByTypeQuery([]int{model.TypeRobot, model.TypeSensor}, []string{excludeId}, nil, nil).String())
 }
 
-func (s *SmartContract) GetServerAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]internal.Asset, error) {
-	assetQuery := fmt.Sprintf(`{"selector":{"type":0,"state":1,"$not":{"id":"%s"}}}`, excludeId)
-	return stringQuery(ctx, assetQuery)
+// -- PAGINATED ASSET QUERIES
+func (s *SmartContract) GetServerAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return stringQueryPage(ctx, assetsByTypeQuery([]int{model.TypeServer}, nil, nil, nil).String(), pageSize, bookmark)
 }
 
-func (s *SmartContract) GetRobotAssets(ctx contractapi.TransactionContextInterface) ([]internal.Asset, error) {
-	assetQuery := `{"selector":{"type":1,"state":1}}`
-	return stringQuery(ctx, assetQuery)
+func (s *SmartContract) GetServerGPUAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return stringQueryPage(ctx, assetsByTypeQuery([]int{model.TypeServer}, nil, gpuFilter, nil).String(), pageSize, bookmark)
 }
 
-func (s *SmartContract) GetRobotAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]internal.Asset, error) {
-	assetQuery := fmt.Sprintf(`{"selector":{"type":1,"state":1,"$not":{"id":"%s"}}}`, excludeId)
-	return stringQuery(ctx, assetQuery)
+func (s *SmartContract) GetServerAssetsExceptIdPage(ctx contractapi.TransactionContextInterface, excludeId string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return stringQueryPage(ctx, assetsByTypeQuery([]int{model.TypeServer}, []string{excludeId}, nil, nil).String(), pageSize, bookmark)
 }
 
-func (s *SmartContract) GetSensorAssets(ctx contractapi.TransactionContextInterface) ([]internal.Asset, error) {
-	assetQuery := `{"selector":{"type":2,"state":1}}`
-	return stringQuery(ctx, assetQuery)
+func (s *SmartContract) GetRobotAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return stringQueryPage(ctx, assetsByTypeQuery([]int{model.TypeRobot}, nil, nil, nil).String(), pageSize, bookmark)
 }
 
-func (s *SmartContract) GetSensorAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]internal.Asset, error) {
-	assetQuery := fmt.Sprintf(`{"selector":{"type":2,"state":1,"$not":{"id":"%s"}}}`, excludeId)
-	return stringQuery(ctx, assetQuery)
+func (s *SmartContract) GetRobotAssetsExceptIdPage(ctx contractapi.TransactionContextInterface, excludeId string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return stringQueryPage(ctx, assetsByTypeQuery([]int{model.TypeRobot}, []string{excludeId}, nil, nil).String(), pageSize, bookmark)
 }
 
-func (s *SmartContract) GetSensorAndRobotAssets(ctx contractapi.TransactionContextInterface) ([]internal.Asset, error) {
-	assetQuery := `{"selector":{"type":{"$in":[1,2]},"state":1}}`
-	return stringQuery(ctx, assetQuery)
+func (s *SmartContract) GetSensorAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return stringQueryPage(ctx, assetsByTypeQuery([]int{model.TypeSensor}, nil, nil, nil).String(), pageSize, bookmark)
 }
 
-func (s *SmartContract) GetSensorAndRobotAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]internal.Asset, error) {
-	assetQuery := fmt.Sprintf(`{"selector":{"type":{"$in":[1,2]},"state":1,"$not":{"id":"%s"}}}`, excludeId)
-	return stringQuery(ctx, assetQuery)
+func (s *SmartContract) GetSensorAssetsExceptIdPage(ctx contractapi.TransactionContextInterface, excludeId string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return stringQueryPage(ctx, assetsByTypeQuery([]int{model.TypeSensor}, []string{excludeId}, nil, nil).String(), pageSize, bookmark)
 }
 
-func stringQuery(ctx contractapi.TransactionContextInterface, queryString string) ([]internal.Asset, error) {
+func (s *SmartContract) GetSensorAndRobotAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return stringQueryPage(ctx, assetsByTypeQuery([]int{model.TypeRobot, model.TypeSensor}, nil, nil, nil).String(), pageSize, bookmark)
+}
+
+func (s *SmartContract) GetSensorAndRobotAssetsExceptIdPage(ctx contractapi.TransactionContextInterface, excludeId string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return stringQueryPage(ctx, assetsByTypeQuery([]int{model.TypeRobot, model.TypeSensor}, []string{excludeId}, nil, nil).String(), pageSize, bookmark)
+}
+
+func stringQuery(ctx contractapi.TransactionContextInterface, queryString string) ([]model.Asset, error) {
 	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
 
 	if err != nil {
-		return nil, err
+		return nil, errs.Internalf("failed to query world state: %v", err)
 	}
 	defer resultsIterator.Close()
 
 	return iteratorSlicer(resultsIterator)
 }
 
-func iteratorSlicer(resultsIterator shim.StateQueryIteratorInterface) ([]internal.Asset, error) {
-	var assets []internal.Asset
+func stringQueryPage(ctx contractapi.TransactionContextInterface, queryString string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	if err := mango.ValidatePageSize(pageSize); err != nil {
+		return model.AssetPage{}, err
+	}
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(queryString, pageSize, bookmark)
+	if err != nil {
+		return model.AssetPage{}, errs.Internalf("failed to query world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	assets, err := iteratorSlicer(resultsIterator)
+	if err != nil {
+		return model.AssetPage{}, err
+	}
+	return model.CreateAssetPage(assets, metadata.FetchedRecordsCount, metadata.Bookmark), nil
+}
+
+// iteratorSlicer reads every asset of the iterator, queries without results return an empty slice
+func iteratorSlicer(resultsIterator shim.StateQueryIteratorInterface) ([]model.Asset, error) {
+	assets := []model.Asset{}
 	for resultsIterator.HasNext() {
 		queryResponse, err := resultsIterator.Next()
 		if err != nil {
-			return nil, err
+			return nil, errs.Internalf("failed to read query results: %v", err)
 		}
-		fmt.Println(queryResponse.Value)
-		fmt.Println(string(queryResponse.Value))
-		asset, err := internal.JsonToAsset(string(queryResponse.Value))
+		asset, err := model.JsonToAsset(string(queryResponse.Value))
 		if err != nil {
-			return nil, err
+			return nil, errs.Internalf("failed to decode the Asset with key: %s. %v", queryResponse.Key, err)
 		}
-		fmt.Println(asset)
 		assets = append(assets, asset)
 	}
 	return assets, nil
@@ -219,22 +443,18 @@ func iteratorSlicer(resultsIterator shim.StateQueryIteratorInterface) ([]interna
 
 // Function for testing CouchDB Queries
 func (s *SmartContract) ExecuteQuery(ctx contractapi.TransactionContextInterface, assetQuery string) ([]string, error) {
-	var result []string
+	result := []string{}
 	resultsIterator, err := ctx.GetStub().GetQueryResult(assetQuery)
 	if err != nil {
-		return result, err
+		return nil, errs.InvalidArgumentf("failed to execute query: %v", err)
 	}
 	defer resultsIterator.Close()
-	if resultsIterator.HasNext() {
-		for resultsIterator.HasNext() {
-			queryResponse, err := resultsIterator.Next()
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, string(queryResponse.Value))
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, errs.Internalf("failed to read query results: %v", err)
 		}
-	} else {
-		return nil, fmt.Errorf("failed to query chaincode. No results found for iterator")
+		result = append(result, string(queryResponse.Value))
 	}
 
 	return result, nil
@@ -0,0 +1,168 @@
+package chaincode
+
+import (
+	"sort"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/access"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// TransferAsset proposes the transfer of the asset to another organization, only for the owner organization or admins.
+// The asset keeps its owner until the receiving organization accepts the transfer
+func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, assetKey string, newOwner string) (model.Asset, error) {
+	asset, err := s.ReadAsset(ctx, assetKey)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	mspID, err := assertAssetModifier(ctx, asset)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	if newOwner == "" || newOwner == asset.Owner {
+		return model.Asset{}, errs.InvalidArgumentf("the Asset with key: %s can not be transferred to %q", assetKey, newOwner)
+	}
+	if asset.PendingOwner != "" {
+		return model.Asset{}, errs.AlreadyExistsf("the Asset with key: %s has a pending transfer to %s", assetKey, asset.PendingOwner)
+	}
+
+	asset.PendingOwner = newOwner
+	err = putOwnershipRecord(ctx, assetKey, model.OwnershipProposed, asset.Owner, newOwner, "", mspID)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	return asset, putAsset(ctx, asset)
+}
+
+// AcceptTransfer completes the pending transfer of the asset, only for clients of the receiving organization.
+// The credentials of the previous owner are deleted, new ones can be sent in the transient map
+func (s *SmartContract) AcceptTransfer(ctx contractapi.TransactionContextInterface, assetKey string) (model.Asset, error) {
+	asset, mspID, err := s.readPendingTransfer(ctx, assetKey)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	err = deleteCredentials(ctx, asset)
+	if err != nil {
+		return model.Asset{}, err
+	}
+
+	previousOwner := asset.Owner
+	asset.Owner = mspID
+	asset.PendingOwner = ""
+	asset.Properties.CredentialsHash, err = putCredentials(ctx, asset, "")
+	if err != nil {
+		return model.Asset{}, err
+	}
+	err = putOwnershipRecord(ctx, assetKey, model.OwnershipAccepted, previousOwner, mspID, "", mspID)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	return asset, putAsset(ctx, asset)
+}
+
+// RejectTransfer refuses the pending transfer of the asset, only for clients of the receiving organization
+func (s *SmartContract) RejectTransfer(ctx contractapi.TransactionContextInterface, assetKey string, reason string) (model.Asset, error) {
+	asset, mspID, err := s.readPendingTransfer(ctx, assetKey)
+	if err != nil {
+		return model.Asset{}, err
+	}
+
+	asset.PendingOwner = ""
+	err = putOwnershipRecord(ctx, assetKey, model.OwnershipRejected, asset.Owner, mspID, reason, mspID)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	return asset, putAsset(ctx, asset)
+}
+
+// CancelTransfer withdraws the pending transfer of the asset, only for the owner organization or admins
+func (s *SmartContract) CancelTransfer(ctx contractapi.TransactionContextInterface, assetKey string, reason string) (model.Asset, error) {
+	asset, err := s.ReadAsset(ctx, assetKey)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	mspID, err := assertAssetModifier(ctx, asset)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	if asset.PendingOwner == "" {
+		return model.Asset{}, errs.NotFoundf("the Asset with key: %s has no pending transfer", assetKey)
+	}
+
+	err = putOwnershipRecord(ctx, assetKey, model.OwnershipCancelled, asset.Owner, asset.PendingOwner, reason, mspID)
+	if err != nil {
+		return model.Asset{}, err
+	}
+	asset.PendingOwner = ""
+	return asset, putAsset(ctx, asset)
+}
+
+// GetOwnershipHistory returns every ownership event of the asset, oldest first. The history is kept after the asset is deleted
+func (s *SmartContract) GetOwnershipHistory(ctx contractapi.TransactionContextInterface, assetKey string) ([]model.OwnershipRecord, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(model.OwnershipRecordObjectType, []string{assetKey})
+	if err != nil {
+		return nil, errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	records := []model.OwnershipRecord{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, errs.Internalf("failed to read query results: %v", err)
+		}
+		record, err := model.JsonToOwnershipRecord(string(queryResponse.Value))
+		if err != nil {
+			return nil, errs.Internalf("failed to decode the ownership history of the Asset with key: %s. %v", assetKey, err)
+		}
+		records = append(records, record)
+	}
+	// COMPOSITE KEYS ARE ORDERED BY TXID, NOT BY TIME
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.TimeNano < records[j].Timestamp.TimeNano
+	})
+	return records, nil
+}
+
+// readPendingTransfer returns the asset and the MSP ID of the client when the transfer was proposed to its organization
+func (s *SmartContract) readPendingTransfer(ctx contractapi.TransactionContextInterface, assetKey string) (model.Asset, string, error) {
+	asset, err := s.ReadAsset(ctx, assetKey)
+	if err != nil {
+		return model.Asset{}, "", err
+	}
+	if asset.PendingOwner == "" {
+		return model.Asset{}, "", errs.NotFoundf("the Asset with key: %s has no pending transfer", assetKey)
+	}
+	mspID, err := access.MSPID(ctx.GetClientIdentity())
+	if err != nil {
+		return model.Asset{}, "", err
+	}
+	if mspID != asset.PendingOwner {
+		return model.Asset{}, "", errs.Unauthorizedf("the transfer of the Asset with key: %s was proposed to %s, not to %s", assetKey, asset.PendingOwner, mspID)
+	}
+	return asset, mspID, nil
+}
+
+func putOwnershipRecord(ctx contractapi.TransactionContextInterface, assetKey string, event string, from string, to string, reason string, submittedBy string) error {
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	txID := ctx.GetStub().GetTxID()
+	compositeKey, err := ctx.GetStub().CreateCompositeKey(model.OwnershipRecordObjectType, []string{assetKey, txID})
+	if err != nil {
+		return errs.InvalidArgumentf("invalid key: %v", err)
+	}
+	record := model.OwnershipRecord{
+		AssetID:     assetKey,
+		Event:       event,
+		From:        from,
+		To:          to,
+		Reason:      reason,
+		SubmittedBy: submittedBy,
+		TxID:        txID,
+		Timestamp:   timestamp,
+	}
+	return errs.Wrap(errs.Internal, ctx.GetStub().PutState(compositeKey, []byte(record.String())))
+}
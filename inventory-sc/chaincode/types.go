@@ -0,0 +1,254 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/access"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/labels"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/mango"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RegisterAssetType adds a new type to the registry, only for admins. Names and codes are unique
+func (s *SmartContract) RegisterAssetType(ctx contractapi.TransactionContextInterface, typeJson string) (model.AssetType, error) {
+	err := access.AssertAdmin(ctx.GetClientIdentity())
+	if err != nil {
+		return model.AssetType{}, err
+	}
+	assetType, err := jsonToValidAssetType(typeJson)
+	if err != nil {
+		return model.AssetType{}, err
+	}
+	assetTypes, err := getAssetTypes(ctx)
+	if err != nil {
+		return model.AssetType{}, err
+	}
+	for _, registered := range assetTypes {
+		if registered.Name == assetType.Name || registered.Code == assetType.Code {
+			return model.AssetType{}, errs.AlreadyExistsf("the asset type %s (%d) is already registered as %s (%d)", assetType.Name, assetType.Code, registered.Name, registered.Code)
+		}
+	}
+	return assetType, putAssetType(ctx, assetType)
+}
+
+// UpdateAssetType changes the description, allowed properties or index of a registered type, only for admins.
+// The code of a type can not be changed, assets refer to it
+func (s *SmartContract) UpdateAssetType(ctx contractapi.TransactionContextInterface, typeJson string) (model.AssetType, error) {
+	err := access.AssertAdmin(ctx.GetClientIdentity())
+	if err != nil {
+		return model.AssetType{}, err
+	}
+	assetType, err := jsonToValidAssetType(typeJson)
+	if err != nil {
+		return model.AssetType{}, err
+	}
+	stored, err := readAssetType(ctx, assetType.Name)
+	if err != nil {
+		return model.AssetType{}, err
+	}
+	if stored.Code != assetType.Code {
+		return model.AssetType{}, errs.InvalidArgumentf("the code of the asset type %s is %d and can not be changed", stored.Name, stored.Code)
+	}
+	return assetType, putAssetType(ctx, assetType)
+}
+
+func (s *SmartContract) GetAssetType(ctx contractapi.TransactionContextInterface, name string) (model.AssetType, error) {
+	return readAssetType(ctx, name)
+}
+
+// GetAssetTypes returns every registered type, ordered by code
+func (s *SmartContract) GetAssetTypes(ctx contractapi.TransactionContextInterface) ([]model.AssetType, error) {
+	return getAssetTypes(ctx)
+}
+
+// GetAssetsByType returns the assets of the type in a placeable state, except the ones in excludeIds.
+// filtersJson is an optional object of allowed properties and the value they must have, e.g. {"gpu": 1},
+// and labelSelector an optional label selector, e.g. "zone=factory-2,tier in (gpu,npu)"
+func (s *SmartContract) GetAssetsByType(ctx contractapi.TransactionContextInterface, typeName string, excludeIds []string, filtersJson string, labelSelector string) ([]model.Asset, error) {
+	query, err := assetsByTypeNameQuery(ctx, typeName, excludeIds, filtersJson, labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	return stringQuery(ctx, query.String())
+}
+
+func (s *SmartContract) GetAssetsByTypePage(ctx contractapi.TransactionContextInterface, typeName string, excludeIds []string, filtersJson string, labelSelector string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	query, err := assetsByTypeNameQuery(ctx, typeName, excludeIds, filtersJson, labelSelector)
+	if err != nil {
+		return model.AssetPage{}, err
+	}
+	return stringQueryPage(ctx, query.String(), pageSize, bookmark)
+}
+
+func assetsByTypeNameQuery(ctx contractapi.TransactionContextInterface, typeName string, excludeIds []string, filtersJson string, labelSelector string) (mango.Query, error) {
+	assetType, err := readAssetType(ctx, typeName)
+	if err != nil {
+		return mango.Query{}, err
+	}
+	filters := map[string]interface{}{}
+	if filtersJson != "" {
+		err = json.Unmarshal([]byte(filtersJson), &filters)
+		if err != nil {
+			return mango.Query{}, errs.InvalidArgumentf("invalid filters: %v", err)
+		}
+	}
+	for property := range filters {
+		if !assetType.AllowsProperty(property) {
+			return mango.Query{}, errs.InvalidArgumentf("the property %s can not be used to filter assets of type %s, allowed properties: %v", property, assetType.Name, assetType.AllowedProperties)
+		}
+	}
+
+	selector, err := parseLabelSelector(labelSelector)
+	if err != nil {
+		return mango.Query{}, err
+	}
+
+	query := assetsByTypeQuery([]int{assetType.Code}, excludeIds, filters, selector)
+	if assetType.Index != "" {
+		query = query.WithIndex(assetType.Index)
+	}
+	return query, nil
+}
+
+// assetsByTypeQuery is the query behind every asset list, only assets in a placeable state are returned.
+// Filters are sorted so every peer builds the same query
+func assetsByTypeQuery(codes []int, excludeIds []string, filters map[string]interface{}, selector labels.Selector) mango.Query {
+	conditions := []mango.Condition{}
+	if len(codes) == 1 {
+		conditions = append(conditions, mango.Eq("type", codes[0]))
+	} else {
+		types := make([]interface{}, len(codes))
+		for i, code := range codes {
+			types[i] = code
+		}
+		conditions = append(conditions, mango.In("type", types...))
+	}
+	conditions = append(conditions, placeableState())
+
+	properties := make([]string, 0, len(filters))
+	for property := range filters {
+		properties = append(properties, property)
+	}
+	sort.Strings(properties)
+	for _, property := range properties {
+		conditions = append(conditions, mango.Eq("properties."+property, filters[property]))
+	}
+
+	switch len(excludeIds) {
+	case 0:
+	case 1:
+		conditions = append(conditions, mango.Ne("id", excludeIds[0]))
+	default:
+		conditions = append(conditions, mango.Nin("id", stringValues(excludeIds)...))
+	}
+	conditions = append(conditions, selector.Conditions("labels")...)
+	return mango.NewQuery(conditions...)
+}
+
+// assertAllowedProperties refuses assets of an unknown type or setting properties their type does not allow
+func assertAllowedProperties(ctx contractapi.TransactionContextInterface, assetJson string, asset model.Asset) error {
+	assetType, err := readAssetTypeByCode(ctx, asset.Type)
+	if err != nil {
+		return err
+	}
+	var posted struct {
+		Properties map[string]interface{} `json:"properties"`
+	}
+	err = json.Unmarshal([]byte(assetJson), &posted)
+	if err != nil {
+		return errs.InvalidArgumentf("invalid asset: %v", err)
+	}
+	for property := range posted.Properties {
+		if property != "schemaVersion" && property != "credentialsHash" && !assetType.AllowsProperty(property) {
+			return errs.InvalidArgumentf("the property %s is not allowed for assets of type %s, allowed properties: %v", property, assetType.Name, assetType.AllowedProperties)
+		}
+	}
+	return nil
+}
+
+// getAssetTypes returns the registry, default types that were not stored yet are included
+func getAssetTypes(ctx contractapi.TransactionContextInterface) ([]model.AssetType, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(model.AssetTypeObjectType, []string{})
+	if err != nil {
+		return nil, errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	assetTypes := []model.AssetType{}
+	stored := make(map[string]bool)
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, errs.Internalf("failed to read query results: %v", err)
+		}
+		assetType, err := model.JsonToAssetType(string(queryResponse.Value))
+		if err != nil {
+			return nil, errs.Internalf("failed to decode the asset type with key: %s. %v", queryResponse.Key, err)
+		}
+		assetTypes = append(assetTypes, assetType)
+		stored[assetType.Name] = true
+	}
+	for _, assetType := range model.DefaultAssetTypes {
+		if !stored[assetType.Name] {
+			assetTypes = append(assetTypes, assetType)
+		}
+	}
+	sort.SliceStable(assetTypes, func(i, j int) bool {
+		return assetTypes[i].Code < assetTypes[j].Code
+	})
+	return assetTypes, nil
+}
+
+func readAssetType(ctx contractapi.TransactionContextInterface, name string) (model.AssetType, error) {
+	assetTypes, err := getAssetTypes(ctx)
+	if err != nil {
+		return model.AssetType{}, err
+	}
+	for _, assetType := range assetTypes {
+		if assetType.Name == name {
+			return assetType, nil
+		}
+	}
+	return model.AssetType{}, errs.NotFoundf("the asset type %s is not registered", name)
+}
+
+func readAssetTypeByCode(ctx contractapi.TransactionContextInterface, code int) (model.AssetType, error) {
+	assetTypes, err := getAssetTypes(ctx)
+	if err != nil {
+		return model.AssetType{}, err
+	}
+	for _, assetType := range assetTypes {
+		if assetType.Code == code {
+			return assetType, nil
+		}
+	}
+	return model.AssetType{}, errs.InvalidArgumentf("the asset type %d is not registered", code)
+}
+
+func putAssetType(ctx contractapi.TransactionContextInterface, assetType model.AssetType) error {
+	compositeKey, err := ctx.GetStub().CreateCompositeKey(model.AssetTypeObjectType, []string{assetType.Name})
+	if err != nil {
+		return errs.InvalidArgumentf("invalid key: %v", err)
+	}
+	return errs.Wrap(errs.Internal, ctx.GetStub().PutState(compositeKey, []byte(assetType.String())))
+}
+
+// jsonToValidAssetType decodes a type posted by an admin, allowed properties must be fields of model.Properties
+func jsonToValidAssetType(typeJson string) (model.AssetType, error) {
+	assetType, err := model.JsonToAssetType(typeJson)
+	if err != nil {
+		return model.AssetType{}, errs.InvalidArgumentf("invalid asset type: %v", err)
+	}
+	if assetType.Name == "" || assetType.Code < 0 {
+		return model.AssetType{}, errs.InvalidArgumentf("the asset type must have a name and a code >= 0")
+	}
+	for _, property := range assetType.AllowedProperties {
+		if !contains(model.PropertyNames, property) {
+			return model.AssetType{}, errs.InvalidArgumentf("unknown property %s for the asset type %s, expected any of %v", property, assetType.Name, model.PropertyNames)
+		}
+	}
+	return assetType, nil
+}
@@ -0,0 +1,111 @@
+package chaincode
+
+import (
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/series"
+	"github.com/dmonteroh/distributed-resources-smartcontract/latency-sc/internal"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Assets stored under a single key (single_upsert mode, CreateAsset and UpdateAsset) are indexed by source and by target
+// under (latencyLatest, source, key) and (latencyLatestTarget, target, key), so the window queries read them by partial key
+// instead of scanning the namespace. The index entries of an asset are replaced with it
+
+// putKeyedLatency stores the asset under key and replaces the index entries of the asset it replaces
+func putKeyedLatency(ctx contractapi.TransactionContextInterface, key string, asset internal.LatencyAsset) error {
+	err := delKeyedLatencyIndex(ctx, key)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutState(key, []byte(asset.String()))
+	if err != nil {
+		return errs.Internalf("failed to put to world state. %v", err)
+	}
+	return forKeyedLatencyIndex(ctx, key, asset, func(indexKey string) error {
+		return ctx.GetStub().PutState(indexKey, []byte(key))
+	})
+}
+
+// delKeyedLatencyIndex deletes the index entries of the asset stored under key, if any
+func delKeyedLatencyIndex(ctx contractapi.TransactionContextInterface, key string) error {
+	assetJson, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return errs.Internalf("failed to read from world state: %v", err)
+	}
+	if assetJson == nil {
+		return nil
+	}
+	asset, err := internal.LatencyAssetJsonToStruct(string(assetJson))
+	if err != nil {
+		return errs.Internalf("failed to decode the Asset with key: %s. %v", key, err)
+	}
+	return forKeyedLatencyIndex(ctx, key, asset, ctx.GetStub().DelState)
+}
+
+// forKeyedLatencyIndex calls f with the key of every index entry of the asset stored under key
+func forKeyedLatencyIndex(ctx contractapi.TransactionContextInterface, key string, asset internal.LatencyAsset, f func(indexKey string) error) error {
+	indexKeys := []string{}
+	sourceKey, err := ctx.GetStub().CreateCompositeKey(internal.LatencyLatestObjectType, []string{asset.Source, key})
+	if err != nil {
+		return errs.InvalidArgumentf("invalid key: %v", err)
+	}
+	indexKeys = append(indexKeys, sourceKey)
+	indexed := map[string]bool{}
+	for _, result := range asset.Results {
+		if indexed[result.Hostname] {
+			continue
+		}
+		indexed[result.Hostname] = true
+		targetKey, err := ctx.GetStub().CreateCompositeKey(internal.LatencyLatestTargetObjectType, []string{result.Hostname, key})
+		if err != nil {
+			return errs.InvalidArgumentf("invalid key: %v", err)
+		}
+		indexKeys = append(indexKeys, targetKey)
+	}
+
+	for _, indexKey := range indexKeys {
+		err = f(indexKey)
+		if err != nil {
+			return errs.Internalf("failed to write the index of %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// keyedLatencyAssets returns the assets stored under a single key found in the index objectType of host
+// (LatencyLatestObjectType for a source, LatencyLatestTargetObjectType for a target) with fromSeconds <= timestamp < toSeconds
+func keyedLatencyAssets(ctx contractapi.TransactionContextInterface, objectType string, host string, fromSeconds int64, toSeconds int64) ([]internal.LatencyAsset, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(objectType, []string{host})
+	if err != nil {
+		return nil, errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	from := series.TimeAttribute(model.Timestamp{TimeSeconds: fromSeconds})
+	to := series.TimeAttribute(model.Timestamp{TimeSeconds: toSeconds})
+	assets := []internal.LatencyAsset{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, errs.Internalf("failed to read query results: %v", err)
+		}
+		key := string(queryResponse.Value)
+		assetJson, err := ctx.GetStub().GetState(key)
+		if err != nil {
+			return nil, errs.Internalf("failed to read from world state: %v", err)
+		}
+		if assetJson == nil {
+			continue
+		}
+		asset, err := internal.LatencyAssetJsonToStruct(string(assetJson))
+		if err != nil {
+			return nil, errs.Internalf("failed to decode the Asset with key: %s. %v", key, err)
+		}
+		at := series.TimeAttribute(asset.Timestamp)
+		if at >= from && at < to {
+			assets = append(assets, asset)
+		}
+	}
+	return assets, nil
+}
@@ -1,10 +1,19 @@
 package chaincode
 
 import (
-	"fmt"
+	"encoding/json"
 	"sort"
+	"strconv"
 	"time"
 
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/access"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/clock"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/invoke"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/mango"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/series"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/validation"
 	"github.com/dmonteroh/distributed-resources-smartcontract/latency-sc/internal"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
@@ -22,26 +31,26 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 	for _, asset := range assets {
 		err := ctx.GetStub().PutState(asset.ID, []byte(asset.String()))
 		if err != nil {
-			return fmt.Errorf("failed to put to world state. %v", err)
+			return errs.Internalf("failed to put to world state. %v", err)
 		}
 	}
 
-	return nil
+	return initConfig(ctx)
 }
 
 // ReadAsset returns the asset stored in the world state with given id.
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, assetKey string) (internal.LatencyAsset, error) {
 	assetJson, err := ctx.GetStub().GetState(assetKey)
 	if err != nil {
-		return internal.LatencyAsset{}, fmt.Errorf("failed to read from world state: %v", err)
+		return internal.LatencyAsset{}, errs.Internalf("failed to read from world state: %v", err)
 	}
 	if assetJson == nil {
-		return internal.LatencyAsset{}, fmt.Errorf("the Asset with key: %s does not exist", assetKey)
+		return internal.LatencyAsset{}, errs.NotFoundf("the Asset with key: %s does not exist", assetKey)
 	}
 
 	asset, err := internal.LatencyAssetJsonToStruct(string(assetJson))
 	if err != nil {
-		return internal.LatencyAsset{}, err
+		return internal.LatencyAsset{}, errs.Internalf("failed to decode the Asset with key: %s. %v", assetKey, err)
 	}
 
 	return asset, nil
@@ -49,57 +58,205 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, a
 
 // CreateAsset issues a new asset to the world state with given details.
 func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, assetJson string) error {
-	asset, err := internal.LatencyAssetJsonToStruct(assetJson)
+	asset, err := jsonToValidLatencyAsset(ctx, assetJson, true)
 	if err != nil {
 		return err
 	}
 
-	// RUN VALIDATIONS
-	if len(asset.Results) == 0 {
-		return fmt.Errorf("no latency results were posted, ignored")
+	exists, err := s.AssetExists(ctx, asset.ID)
+	if err != nil {
+		return err
 	}
-	if asset.ID == "" {
-		return fmt.Errorf("latency results was posted without ID, ignored")
+	if exists {
+		return errs.AlreadyExistsf("the Asset for %s already exists", asset.ID)
+	}
+	return putKeyedLatency(ctx, asset.ID, asset)
+}
+
+// UpdateAsset updates an existing asset in the world state with provided parameters.
+func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, assetJson string) error {
+	asset, err := jsonToValidLatencyAsset(ctx, assetJson, false)
+	if err != nil {
+		return err
 	}
 
 	exists, err := s.AssetExists(ctx, asset.ID)
 	if err != nil {
 		return err
 	}
-	if exists {
-		return fmt.Errorf("the Asset for %s already exists", asset.ID)
+	if !exists {
+		return errs.NotFoundf("the Asset for %s does not exist", asset.ID)
 	}
-	validJson := []byte(asset.String())
 
-	return ctx.GetStub().PutState(asset.ID, validJson)
+	return putKeyedLatency(ctx, asset.ID, asset)
 }
 
-// UpdateAsset updates an existing asset in the world state with provided parameters.
-func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, assetJson string) error {
+// UpsertLatency stores the results posted by a latency collector under the key derived from the mode:
+// single_insert adds the measurement to the time series of the source and single_upsert creates or replaces the asset of the source
+func (s *SmartContract) UpsertLatency(ctx contractapi.TransactionContextInterface, resultsJson string, mode string) (internal.LatencyAsset, error) {
+	results, err := internal.LatencyResultsJsonToStruct(resultsJson)
+	if err != nil {
+		return internal.LatencyAsset{}, errs.InvalidArgumentf("invalid latency results: %v", err)
+	}
+	report, err := validateLatency(ctx, results, true)
+	if err != nil {
+		return internal.LatencyAsset{}, err
+	}
+	err = report.Err("latency results")
+	if err != nil {
+		return internal.LatencyAsset{}, err
+	}
+	id, err := internal.CreateLatencyID(mode, results.Source, results.Timestamp)
+	if err != nil {
+		return internal.LatencyAsset{}, errs.InvalidArgumentf("%v", err)
+	}
+	asset := internal.CreateLatencyAsset(id, results)
+
+	key := asset.ID
+	if mode == internal.ModeSingleInsert {
+		key, err = series.Key(ctx.GetStub(), internal.LatencyObjectType, asset.Source, asset.Timestamp)
+		if err != nil {
+			return internal.LatencyAsset{}, err
+		}
+		exists, err := s.AssetExists(ctx, key)
+		if err != nil {
+			return internal.LatencyAsset{}, err
+		}
+		if exists {
+			return internal.LatencyAsset{}, errs.AlreadyExistsf("the Asset for %s already exists", asset.ID)
+		}
+		err = putTargetIndex(ctx, asset, key)
+		if err != nil {
+			return internal.LatencyAsset{}, err
+		}
+	}
+	if mode == internal.ModeSingleUpsert {
+		return asset, putKeyedLatency(ctx, key, asset)
+	}
+	return asset, errs.Wrap(errs.Internal, ctx.GetStub().PutState(key, []byte(asset.String())))
+}
+
+// putTargetIndex adds the measurement stored under sampleKey to the target index of every host it measured
+func putTargetIndex(ctx contractapi.TransactionContextInterface, asset internal.LatencyAsset, sampleKey string) error {
+	indexed := map[string]bool{}
+	for _, result := range asset.Results {
+		if indexed[result.Hostname] {
+			continue
+		}
+		indexed[result.Hostname] = true
+		key, err := series.IndexKey(ctx.GetStub(), internal.LatencyTargetObjectType, result.Hostname, asset.Timestamp, asset.Source)
+		if err != nil {
+			return err
+		}
+		err = ctx.GetStub().PutState(key, []byte(sampleKey))
+		if err != nil {
+			return errs.Internalf("failed to put to world state. %v", err)
+		}
+	}
+	return nil
+}
+
+// GetLastLatencies returns the n latest measurements of the time series of the source, newest first
+func (s *SmartContract) GetLastLatencies(ctx contractapi.TransactionContextInterface, source string, n int) ([]internal.LatencyAsset, error) {
+	samples, err := series.Last(ctx.GetStub(), internal.LatencyObjectType, source, n)
+	if err != nil {
+		return nil, err
+	}
+	return samplesToLatencyAssets(samples)
+}
+
+// GetLatenciesBetween returns the measurements of the time series of the source with fromSeconds <= timestamp < toSeconds (unix seconds), newest first
+func (s *SmartContract) GetLatenciesBetween(ctx contractapi.TransactionContextInterface, source string, fromSeconds int64, toSeconds int64) ([]internal.LatencyAsset, error) {
+	samples, err := series.Between(ctx.GetStub(), internal.LatencyObjectType, source, fromSeconds, toSeconds)
+	if err != nil {
+		return nil, err
+	}
+	assets, err := samplesToLatencyAssets(samples)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(assets)-1; i < j; i, j = i+1, j-1 {
+		assets[i], assets[j] = assets[j], assets[i]
+	}
+	return assets, nil
+}
+
+func samplesToLatencyAssets(samples []string) ([]internal.LatencyAsset, error) {
+	assets := []internal.LatencyAsset{}
+	for _, sample := range samples {
+		asset, err := internal.LatencyAssetJsonToStruct(sample)
+		if err != nil {
+			return nil, errs.Internalf("failed to decode the latency results. %v", err)
+		}
+		assets = append(assets, asset)
+	}
+	return assets, nil
+}
+
+// jsonToValidLatencyAsset decodes a latency asset posted by the client, it must have an ID and valid results (see validateLatency)
+func jsonToValidLatencyAsset(ctx contractapi.TransactionContextInterface, assetJson string, created bool) (internal.LatencyAsset, error) {
 	asset, err := internal.LatencyAssetJsonToStruct(assetJson)
 	if err != nil {
-		return err
+		return internal.LatencyAsset{}, errs.InvalidArgumentf("invalid latency results: %v", err)
 	}
 
 	// RUN VALIDATIONS
-	if len(asset.Results) == 0 {
-		return fmt.Errorf("no latency results were posted, ignored")
-	}
 	if asset.ID == "" {
-		return fmt.Errorf("latency results was posted without ID, ignored")
+		return internal.LatencyAsset{}, errs.InvalidArgumentf("latency results was posted without ID, ignored")
+	}
+	results := internal.LatencyResults{Source: asset.Source, Timestamp: asset.Timestamp, Results: asset.Results}
+	report, err := validateLatency(ctx, results, created)
+	if err != nil {
+		return internal.LatencyAsset{}, err
 	}
+	return asset, report.Err("latency results")
+}
 
-	exists, err := s.AssetExists(ctx, asset.ID)
+// ValidateLatencyResults returns the field-level violations of the results as if they were posted now, without storing them
+func (s *SmartContract) ValidateLatencyResults(ctx contractapi.TransactionContextInterface, resultsJson string) (validation.Report, error) {
+	results, err := internal.LatencyResultsJsonToStruct(resultsJson)
 	if err != nil {
-		return err
+		return validation.Report{}, errs.InvalidArgumentf("invalid latency results: %v", err)
 	}
-	if exists {
-		return fmt.Errorf("the Asset for %s already exists", asset.ID)
+	return validateLatency(ctx, results, true)
+}
+
+// validateLatency checks the results and that the source and every target are registered in the inventory.
+// New results (created) are also checked against the transaction timestamp, results replaced by UpdateAsset can be historic
+func validateLatency(ctx contractapi.TransactionContextInterface, results internal.LatencyResults, created bool) (validation.Report, error) {
+	var now time.Time
+	if created {
+		var err error
+		now, err = clock.New(ctx.GetStub()).Now()
+		if err != nil {
+			return validation.Report{}, err
+		}
 	}
 
-	validJson := []byte(asset.String())
+	report := validation.NewReport()
+	internal.ValidateLatencyResults(&report, results, now)
 
-	return ctx.GetStub().PutState(asset.ID, validJson)
+	config, err := invoke.GetConfig(ctx.GetStub())
+	if err != nil {
+		return validation.Report{}, err
+	}
+	inventory := config.InventoryChaincode()
+	err = report.Registered(ctx.GetStub(), inventory, "source", results.Source)
+	if err != nil {
+		return validation.Report{}, err
+	}
+	checked := map[string]bool{results.Source: true}
+	for i, result := range results.Results {
+		if checked[result.Hostname] {
+			continue
+		}
+		checked[result.Hostname] = true
+		err = report.Registered(ctx.GetStub(), inventory, validation.Index("results", i, "hostname"), result.Hostname)
+		if err != nil {
+			return validation.Report{}, err
+		}
+	}
+	return report, nil
 }
 
 // DeleteAsset deletes an given asset from the world state.
@@ -109,45 +266,90 @@ func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface,
 		return err
 	}
 	if !exists {
-		return fmt.Errorf("the Stats for %s do not exist", assetKey)
+		return errs.NotFoundf("the Stats for %s do not exist", assetKey)
+	}
+	// ONLY THE ASSETS STORED UNDER A SINGLE KEY ARE INDEXED, TIME SERIES KEYS ARE COMPOSITE
+	if assetKey[0] != 0 {
+		err = delKeyedLatencyIndex(ctx, assetKey)
+		if err != nil {
+			return err
+		}
 	}
 
-	return ctx.GetStub().DelState(assetKey)
+	return errs.Wrap(errs.Internal, ctx.GetStub().DelState(assetKey))
 }
 
 // AssetExists returns true when asset with given ID exists in world state
 func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface, assetKey string) (bool, error) {
 	statJSON, err := ctx.GetStub().GetState(assetKey)
 	if err != nil {
-		return false, fmt.Errorf("failed to read from world state: %v", err)
+		return false, errs.Internalf("failed to read from world state: %v", err)
 	}
 
 	return statJSON != nil, nil
 }
 
-// CURRENTLY IN TO-DO (NO OWNERSHIP REQUIRED ATM)
-// TransferAsset updates the owner field of asset with given id in world state.
-// func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, statIP string, newStatIP string) (string, error) {
-// 	statObject, err := s.ReadAsset(ctx, statIP)
-// 	if err != nil {
-// 		return "", err
-// 	}
-
-// 	statObject.ID = newStatIP
-
-// 	return statObject.String(), ctx.GetStub().PutState(newStatIP, []byte(statObject.String()))
-// }
-
 // GetAllAssets returns all assets found in world state
 func (s *SmartContract) GetAllAssets(ctx contractapi.TransactionContextInterface) ([]internal.LatencyAsset, error) {
 	// range query with empty string for startKey and endKey does an
 	// open-ended query of all assets in the chaincode namespace.
 	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+	assets, err := iteratorSlicer(resultsIterator)
+	if err != nil {
+		return nil, err
+	}
+
+	// RANGE QUERIES SKIP COMPOSITE KEYS, THE TIME SERIES ARE READ SEPARATELY
+	seriesIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(internal.LatencyObjectType, []string{})
+	if err != nil {
+		return nil, errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer seriesIterator.Close()
+	samples, err := iteratorSlicer(seriesIterator)
 	if err != nil {
 		return nil, err
 	}
+	assets = append(assets, samples...)
+	sort.SliceStable(assets, func(i, j int) bool {
+		return assets[i].Timestamp.TimeSeconds > assets[j].Timestamp.TimeSeconds
+	})
+	return assets, nil
+}
+
+// GetLatencySamplesPage returns a page of the time series of the source (single_insert mode), oldest first, or of every source when source is empty.
+// An empty bookmark starts from the first measurement
+func (s *SmartContract) GetLatencySamplesPage(ctx contractapi.TransactionContextInterface, source string, pageSize int32, bookmark string) (internal.LatencyAssetPage, error) {
+	samples, fetchedCount, next, err := series.Page(ctx.GetStub(), internal.LatencyObjectType, source, pageSize, bookmark)
+	if err != nil {
+		return internal.LatencyAssetPage{}, err
+	}
+	assets, err := samplesToLatencyAssets(samples)
+	if err != nil {
+		return internal.LatencyAssetPage{}, err
+	}
+	return internal.CreateLatencyAssetPage(assets, fetchedCount, next), nil
+}
+
+// GetAllAssetsPage returns a page of the assets stored under a single key (single_upsert mode), an empty bookmark starts from the first asset.
+// Range queries skip composite keys, the time series are paged with GetLatencySamplesPage
+func (s *SmartContract) GetAllAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (internal.LatencyAssetPage, error) {
+	if err := mango.ValidatePageSize(pageSize); err != nil {
+		return internal.LatencyAssetPage{}, err
+	}
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", pageSize, bookmark)
+	if err != nil {
+		return internal.LatencyAssetPage{}, errs.Internalf("failed to read from world state: %v", err)
+	}
 	defer resultsIterator.Close()
-	return iteratorSlicer(resultsIterator)
+	assets, err := readLatencyAssets(resultsIterator, "")
+	if err != nil {
+		return internal.LatencyAssetPage{}, err
+	}
+	return internal.CreateLatencyAssetPage(assets, metadata.FetchedRecordsCount, metadata.Bookmark), nil
 }
 
 func filterLatencyTarget(target string, results []internal.LatencyResult) []internal.LatencyResult {
@@ -162,29 +364,27 @@ func filterLatencyTarget(target string, results []internal.LatencyResult) []inte
 	return filteredResults
 }
 
-func iteratorSlicerTarget(ctx contractapi.TransactionContextInterface, queryString string, target string) ([]internal.LatencyAsset, error) {
-	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+func iteratorSlicer(resultsIterator shim.StateQueryIteratorInterface) ([]internal.LatencyAsset, error) {
+	return readLatencyAssets(resultsIterator, "")
+}
 
-	if err != nil {
-		return nil, err
-	}
-	defer resultsIterator.Close()
-	var assets []internal.LatencyAsset
-	if resultsIterator.HasNext() {
-		for resultsIterator.HasNext() {
-			queryResponse, err := resultsIterator.Next()
-			if err != nil {
-				return nil, err
-			}
-			asset, err := internal.LatencyAssetJsonToStruct(string(queryResponse.Value))
-			if err != nil {
-				return nil, err
-			}
+// readLatencyAssets reads every asset of the iterator, newest first. When target is set only its results are kept.
+// Queries without results return an empty slice
+func readLatencyAssets(resultsIterator shim.StateQueryIteratorInterface, target string) ([]internal.LatencyAsset, error) {
+	assets := []internal.LatencyAsset{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, errs.Internalf("failed to read query results: %v", err)
+		}
+		asset, err := internal.LatencyAssetJsonToStruct(string(queryResponse.Value))
+		if err != nil {
+			return nil, errs.Internalf("failed to decode the Asset with key: %s. %v", queryResponse.Key, err)
+		}
+		if target != "" {
 			asset.Results = filterLatencyTarget(target, asset.Results)
-			assets = append(assets, asset)
 		}
-	} else {
-		return nil, fmt.Errorf("failed to query chaincode. No results found for iterator")
+		assets = append(assets, asset)
 	}
 
 	sort.Slice(assets, func(i, j int) bool {
@@ -194,61 +394,196 @@ func iteratorSlicerTarget(ctx contractapi.TransactionContextInterface, queryStri
 	return assets, nil
 }
 
-func iteratorSlicer(resultsIterator shim.StateQueryIteratorInterface) ([]internal.LatencyAsset, error) {
-	var assets []internal.LatencyAsset
-	if resultsIterator.HasNext() {
-		for resultsIterator.HasNext() {
-			queryResponse, err := resultsIterator.Next()
-			if err != nil {
-				return nil, err
-			}
-			asset, err := internal.LatencyAssetJsonToStruct(string(queryResponse.Value))
-			if err != nil {
-				return nil, err
-			}
-			assets = append(assets, asset)
+// sortLatencyAssets sorts the assets newest first
+func sortLatencyAssets(assets []internal.LatencyAsset) {
+	sort.SliceStable(assets, func(i, j int) bool {
+		return series.TimeAttribute(assets[i].Timestamp) > series.TimeAttribute(assets[j].Timestamp)
+	})
+}
+
+// sourceLatencyBetween returns the latency measured by source with fromSeconds <= timestamp < toSeconds, newest first:
+// its time series (single_insert mode) and its assets stored under a single key
+func sourceLatencyBetween(ctx contractapi.TransactionContextInterface, source string, fromSeconds int64, toSeconds int64) ([]internal.LatencyAsset, error) {
+	samples, err := series.Between(ctx.GetStub(), internal.LatencyObjectType, source, fromSeconds, toSeconds)
+	if err != nil {
+		return nil, err
+	}
+	assets, err := samplesToLatencyAssets(samples)
+	if err != nil {
+		return nil, err
+	}
+	keyed, err := keyedLatencyAssets(ctx, internal.LatencyLatestObjectType, source, fromSeconds, toSeconds)
+	if err != nil {
+		return nil, err
+	}
+	assets = append(assets, keyed...)
+	sortLatencyAssets(assets)
+	return assets, nil
+}
+
+// sourceLatencyPage returns a page of sourceLatencyBetween in key order, oldest first, the assets stored under a single key
+// coming with the last page. The bookmark is the key the next page starts from
+func sourceLatencyPage(ctx contractapi.TransactionContextInterface, source string, fromSeconds int64, toSeconds int64, pageSize int32, bookmark string) (internal.LatencyAssetPage, error) {
+	samples, next, err := series.BetweenPage(ctx.GetStub(), internal.LatencyObjectType, source, fromSeconds, toSeconds, pageSize, bookmark)
+	if err != nil {
+		return internal.LatencyAssetPage{}, err
+	}
+	assets, err := samplesToLatencyAssets(samples)
+	if err != nil {
+		return internal.LatencyAssetPage{}, err
+	}
+	if next == "" {
+		keyed, err := keyedLatencyAssets(ctx, internal.LatencyLatestObjectType, source, fromSeconds, toSeconds)
+		if err != nil {
+			return internal.LatencyAssetPage{}, err
 		}
-	} else {
-		return nil, fmt.Errorf("failed to query chaincode. No results found for iterator")
+		assets = append(assets, keyed...)
 	}
+	return internal.CreateLatencyAssetPage(assets, int32(len(assets)), next), nil
+}
 
-	sort.Slice(assets, func(i, j int) bool {
-		return assets[i].Timestamp.TimeSeconds > assets[j].Timestamp.TimeSeconds
-	})
+// targetLatencyBetween returns the latency measured towards target with fromSeconds <= timestamp < toSeconds, newest first,
+// keeping only the results of target: the measurements of the target index (single_insert mode) and the assets stored under a single key
+func targetLatencyBetween(ctx contractapi.TransactionContextInterface, target string, fromSeconds int64, toSeconds int64) ([]internal.LatencyAsset, error) {
+	sampleKeys, err := series.Between(ctx.GetStub(), internal.LatencyTargetObjectType, target, fromSeconds, toSeconds)
+	if err != nil {
+		return nil, err
+	}
+	assets, err := readTargetSamples(ctx, sampleKeys)
+	if err != nil {
+		return nil, err
+	}
+	keyed, err := keyedLatencyAssets(ctx, internal.LatencyLatestTargetObjectType, target, fromSeconds, toSeconds)
+	if err != nil {
+		return nil, err
+	}
+	assets = filterAssetsTarget(target, append(assets, keyed...))
+	sortLatencyAssets(assets)
+	return assets, nil
+}
 
+// targetLatencyPage returns a page of targetLatencyBetween in key order, oldest first, the assets stored under a single key
+// coming with the last page. The bookmark is the key the next page starts from
+func targetLatencyPage(ctx contractapi.TransactionContextInterface, target string, fromSeconds int64, toSeconds int64, pageSize int32, bookmark string) (internal.LatencyAssetPage, error) {
+	sampleKeys, next, err := series.BetweenPage(ctx.GetStub(), internal.LatencyTargetObjectType, target, fromSeconds, toSeconds, pageSize, bookmark)
+	if err != nil {
+		return internal.LatencyAssetPage{}, err
+	}
+	assets, err := readTargetSamples(ctx, sampleKeys)
+	if err != nil {
+		return internal.LatencyAssetPage{}, err
+	}
+	if next == "" {
+		keyed, err := keyedLatencyAssets(ctx, internal.LatencyLatestTargetObjectType, target, fromSeconds, toSeconds)
+		if err != nil {
+			return internal.LatencyAssetPage{}, err
+		}
+		assets = append(assets, keyed...)
+	}
+	assets = filterAssetsTarget(target, assets)
+	return internal.CreateLatencyAssetPage(assets, int32(len(assets)), next), nil
+}
+
+// readTargetSamples reads the measurements referenced by the entries of the target index
+func readTargetSamples(ctx contractapi.TransactionContextInterface, sampleKeys []string) ([]internal.LatencyAsset, error) {
+	assets := []internal.LatencyAsset{}
+	for _, sampleKey := range sampleKeys {
+		sample, err := ctx.GetStub().GetState(sampleKey)
+		if err != nil {
+			return nil, errs.Internalf("failed to read from world state: %v", err)
+		}
+		// THE MEASUREMENT WAS DELETED
+		if sample == nil {
+			continue
+		}
+		asset, err := internal.LatencyAssetJsonToStruct(string(sample))
+		if err != nil {
+			return nil, errs.Internalf("failed to decode the latency results. %v", err)
+		}
+		assets = append(assets, asset)
+	}
 	return assets, nil
 }
 
-func stringQuery(ctx contractapi.TransactionContextInterface, queryString string) ([]internal.LatencyAsset, error) {
-	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+// filterAssetsTarget keeps only the results of target in every asset
+func filterAssetsTarget(target string, assets []internal.LatencyAsset) []internal.LatencyAsset {
+	for i := range assets {
+		assets[i].Results = filterLatencyTarget(target, assets[i].Results)
+	}
+	return assets
+}
 
+// GetAssetListTimeSource returns the latency measured by source during the last minutes, "now" being the transaction timestamp
+func (s *SmartContract) GetAssetListTimeSource(ctx contractapi.TransactionContextInterface, source string, minutes int) ([]internal.LatencyAsset, error) {
+	fromSeconds, toSeconds, err := clock.Window(clock.New(ctx.GetStub()), minutes)
 	if err != nil {
 		return nil, err
 	}
-	defer resultsIterator.Close()
+	return s.GetAssetListRangeSource(ctx, source, fromSeconds, toSeconds)
+}
 
-	return iteratorSlicer(resultsIterator)
+// GetAssetListRangeSource returns the latency measured by source with fromSeconds <= timestamp < toSeconds (unix seconds)
+func (s *SmartContract) GetAssetListRangeSource(ctx contractapi.TransactionContextInterface, source string, fromSeconds int64, toSeconds int64) ([]internal.LatencyAsset, error) {
+	return sourceLatencyBetween(ctx, source, fromSeconds, toSeconds)
 }
 
-func (s *SmartContract) GetAssetListTimeSource(ctx contractapi.TransactionContextInterface, source string, minutes int) ([]internal.LatencyAsset, error) {
-	timeStart := time.Now()
-	timeEnd := timeStart.Add(time.Duration(-time.Duration(minutes) * time.Minute))
-	assetQuery := fmt.Sprintf(`{"selector": {"source": "%s","timestamp.timeSeconds": {"$lt": %d,"$gte": %d}}}`, source, timeStart.Unix(), timeEnd.Unix())
-	return stringQuery(ctx, assetQuery)
+// GetAssetListTimeSourcePage returns a page of GetAssetListTimeSource
+func (s *SmartContract) GetAssetListTimeSourcePage(ctx contractapi.TransactionContextInterface, source string, minutes int, pageSize int32, bookmark string) (internal.LatencyAssetPage, error) {
+	fromSeconds, toSeconds, err := clock.Window(clock.New(ctx.GetStub()), minutes)
+	if err != nil {
+		return internal.LatencyAssetPage{}, err
+	}
+	return s.GetAssetListRangeSourcePage(ctx, source, fromSeconds, toSeconds, pageSize, bookmark)
 }
 
+// GetAssetListRangeSourcePage returns a page of GetAssetListRangeSource, oldest first (see sourceLatencyPage)
+func (s *SmartContract) GetAssetListRangeSourcePage(ctx contractapi.TransactionContextInterface, source string, fromSeconds int64, toSeconds int64, pageSize int32, bookmark string) (internal.LatencyAssetPage, error) {
+	return sourceLatencyPage(ctx, source, fromSeconds, toSeconds, pageSize, bookmark)
+}
+
+// GetAssetListTimeTarget returns the latency measured towards target during the last minutes, "now" being the transaction timestamp
 func (s *SmartContract) GetAssetListTimeTarget(ctx contractapi.TransactionContextInterface, target string, minutes int) ([]internal.LatencyAsset, error) {
-	timeStart := time.Now()
-	timeEnd := timeStart.Add(time.Duration(-time.Duration(minutes) * time.Minute))
-	assetQuery := fmt.Sprintf(`{"selector": {"results": {"$elemMatch": {"hostname": "%s"}},"timestamp.timeSeconds": {"$lt": %d,"$gte": %d}}}`, target, timeStart.Unix(), timeEnd.Unix())
-	return iteratorSlicerTarget(ctx, assetQuery, target)
+	fromSeconds, toSeconds, err := clock.Window(clock.New(ctx.GetStub()), minutes)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAssetListRangeTarget(ctx, target, fromSeconds, toSeconds)
+}
+
+// GetAssetListRangeTarget returns the latency measured towards target with fromSeconds <= timestamp < toSeconds (unix seconds)
+func (s *SmartContract) GetAssetListRangeTarget(ctx contractapi.TransactionContextInterface, target string, fromSeconds int64, toSeconds int64) ([]internal.LatencyAsset, error) {
+	return targetLatencyBetween(ctx, target, fromSeconds, toSeconds)
 }
 
-func (s *SmartContract) GetAnalysisTimeTarget(ctx contractapi.TransactionContextInterface, target string, minutes int) ([]internal.LatencyAnalysis, error) {
-	var targetAnalysis []internal.LatencyAnalysis
-	latencyAssetList, err := s.GetAssetListTimeTarget(ctx, target, minutes)
+// GetAssetListTimeTargetPage returns a page of GetAssetListTimeTarget
+func (s *SmartContract) GetAssetListTimeTargetPage(ctx contractapi.TransactionContextInterface, target string, minutes int, pageSize int32, bookmark string) (internal.LatencyAssetPage, error) {
+	fromSeconds, toSeconds, err := clock.Window(clock.New(ctx.GetStub()), minutes)
 	if err != nil {
-		return targetAnalysis, err
+		return internal.LatencyAssetPage{}, err
+	}
+	return s.GetAssetListRangeTargetPage(ctx, target, fromSeconds, toSeconds, pageSize, bookmark)
+}
+
+// GetAssetListRangeTargetPage returns a page of GetAssetListRangeTarget, oldest first (see targetLatencyPage)
+func (s *SmartContract) GetAssetListRangeTargetPage(ctx contractapi.TransactionContextInterface, target string, fromSeconds int64, toSeconds int64, pageSize int32, bookmark string) (internal.LatencyAssetPage, error) {
+	return targetLatencyPage(ctx, target, fromSeconds, toSeconds, pageSize, bookmark)
+}
+
+// GetAnalysisTimeTarget analyzes the latency towards target during the last minutes, "now" being the transaction timestamp
+func (s *SmartContract) GetAnalysisTimeTarget(ctx contractapi.TransactionContextInterface, target string, minutes int) ([]model.LatencyAnalysis, error) {
+	fromSeconds, toSeconds, err := clock.Window(clock.New(ctx.GetStub()), minutes)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAnalysisRangeTarget(ctx, target, fromSeconds, toSeconds)
+}
+
+// GetAnalysisRangeTarget analyzes the latency towards target with fromSeconds <= timestamp < toSeconds (unix seconds)
+func (s *SmartContract) GetAnalysisRangeTarget(ctx contractapi.TransactionContextInterface, target string, fromSeconds int64, toSeconds int64) ([]model.LatencyAnalysis, error) {
+	targetAnalysis := []model.LatencyAnalysis{}
+	latencyAssetList, err := s.GetAssetListRangeTarget(ctx, target, fromSeconds, toSeconds)
+	if err != nil {
+		return nil, err
 	}
 	latencySelection := make(map[string][]int64)
 
@@ -261,9 +596,9 @@ func (s *SmartContract) GetAnalysisTimeTarget(ctx contractapi.TransactionContext
 	}
 
 	for k, v := range latencySelection {
-		var latAnalysis internal.LatencyAnalysis
+		var latAnalysis model.LatencyAnalysis
 		latAnalysis.Target = target
-		latAnalysis.Duration = minutes
+		latAnalysis.Duration = clock.WindowMinutes(fromSeconds, toSeconds)
 		latAnalysis.Hostname = k
 		latAnalysis.LatencySummary = v
 		latAnalysis.LatencyCount = len(v)
@@ -271,164 +606,167 @@ func (s *SmartContract) GetAnalysisTimeTarget(ctx contractapi.TransactionContext
 		targetAnalysis = append(targetAnalysis, latAnalysis)
 	}
 
+	// MAP ITERATION ORDER IS RANDOM, SORTED SO EVERY ENDORSING PEER RETURNS THE SAME RESULT
+	sort.Slice(targetAnalysis, func(i, j int) bool {
+		return targetAnalysis[i].Hostname < targetAnalysis[j].Hostname
+	})
+
 	return targetAnalysis, nil
 }
 
-// INVETORY SMART CONTRACT INVOKATION
-func (s *SmartContract) GetServerAssets(ctx contractapi.TransactionContextInterface) ([]internal.Asset, error) {
-	params := []string{"GetServerAssets"}
-	queryArgs := make([][]byte, len(params))
-	for i, arg := range params {
-		queryArgs[i] = []byte(arg)
-	}
-
-	response := ctx.GetStub().InvokeChaincode("inventory-sc", queryArgs, "mychannel")
-	if response.Status != shim.OK {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", response.Payload)
+// CONFIGURATION OF THE INVOKED SMART CONTRACTS
+// SetConfig stores the names of the invoked Smart Contracts and their channel, only for admin clients
+func (s *SmartContract) SetConfig(ctx contractapi.TransactionContextInterface, configJson string) (invoke.Config, error) {
+	err := access.AssertAdmin(ctx.GetClientIdentity())
+	if err != nil {
+		return invoke.Config{}, err
 	}
-
-	assetArray, err := internal.JsonToAssetArray(string(response.GetPayload()))
+	config, err := invoke.JsonToConfig(configJson)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", err)
+		return invoke.Config{}, err
 	}
-	return assetArray, nil
+	return invoke.PutConfig(ctx.GetStub(), config)
 }
 
-func (s *SmartContract) GetServerAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]internal.Asset, error) {
-	params := []string{"GetServerAssetsExceptId", excludeId}
-	queryArgs := make([][]byte, len(params))
-	for i, arg := range params {
-		queryArgs[i] = []byte(arg)
-	}
+// GetConfig returns the names of the invoked Smart Contracts and their channel
+func (s *SmartContract) GetConfig(ctx contractapi.TransactionContextInterface) (invoke.Config, error) {
+	return invoke.GetConfig(ctx.GetStub())
+}
 
-	response := ctx.GetStub().InvokeChaincode("inventory-sc", queryArgs, "mychannel")
-	if response.Status != shim.OK {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", response.Payload)
-	}
+// INVETORY SMART CONTRACT INVOKATION
+func (s *SmartContract) GetServerAssets(ctx contractapi.TransactionContextInterface) ([]model.Asset, error) {
+	return queryInventory(ctx, "GetServerAssets")
+}
 
-	assetArray, err := internal.JsonToAssetArray(string(response.GetPayload()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", err)
-	}
-	return assetArray, nil
+func (s *SmartContract) GetServerAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]model.Asset, error) {
+	return queryInventory(ctx, "GetServerAssetsExceptId", excludeId)
 }
 
-func (s *SmartContract) GetRobotAssets(ctx contractapi.TransactionContextInterface) ([]internal.Asset, error) {
-	params := []string{"GetRobotAssets"}
-	queryArgs := make([][]byte, len(params))
-	for i, arg := range params {
-		queryArgs[i] = []byte(arg)
-	}
+func (s *SmartContract) GetRobotAssets(ctx contractapi.TransactionContextInterface) ([]model.Asset, error) {
+	return queryInventory(ctx, "GetRobotAssets")
+}
 
-	response := ctx.GetStub().InvokeChaincode("inventory-sc", queryArgs, "mychannel")
-	if response.Status != shim.OK {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", response.Payload)
-	}
+func (s *SmartContract) GetRobotAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]model.Asset, error) {
+	return queryInventory(ctx, "GetRobotAssetsExceptId", excludeId)
+}
 
-	assetArray, err := internal.JsonToAssetArray(string(response.GetPayload()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", err)
-	}
-	return assetArray, nil
+func (s *SmartContract) GetSensorAssets(ctx contractapi.TransactionContextInterface) ([]model.Asset, error) {
+	return queryInventory(ctx, "GetSensorAssets")
 }
 
-func (s *SmartContract) GetRobotAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]internal.Asset, error) {
-	params := []string{"GetRobotAssetsExceptId", excludeId}
-	queryArgs := make([][]byte, len(params))
-	for i, arg := range params {
-		queryArgs[i] = []byte(arg)
-	}
+func (s *SmartContract) GetSensorAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]model.Asset, error) {
+	return queryInventory(ctx, "GetSensorAssetsExceptId", excludeId)
+}
 
-	response := ctx.GetStub().InvokeChaincode("inventory-sc", queryArgs, "mychannel")
-	if response.Status != shim.OK {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", response.Payload)
-	}
+func (s *SmartContract) GetSensorAndRobotAssets(ctx contractapi.TransactionContextInterface) ([]model.Asset, error) {
+	return queryInventory(ctx, "GetSensorAndRobotAssets")
+}
 
-	assetArray, err := internal.JsonToAssetArray(string(response.GetPayload()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", err)
-	}
-	return assetArray, nil
+func (s *SmartContract) GetSensorAndRobotAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]model.Asset, error) {
+	return queryInventory(ctx, "GetSensorAndRobotAssetsExceptId", excludeId)
 }
 
-func (s *SmartContract) GetSensorAssets(ctx contractapi.TransactionContextInterface) ([]internal.Asset, error) {
-	params := []string{"GetSensorAssets"}
-	queryArgs := make([][]byte, len(params))
-	for i, arg := range params {
-		queryArgs[i] = []byte(arg)
-	}
+// GetAssetsByType passes the type, property filters and label selector (e.g. "zone=factory-2") through to the inventory
+func (s *SmartContract) GetAssetsByType(ctx contractapi.TransactionContextInterface, typeName string, excludeIds []string, filtersJson string, labelSelector string) ([]model.Asset, error) {
+	return queryInventory(ctx, "GetAssetsByType", typeName, idsToJson(excludeIds), filtersJson, labelSelector)
+}
 
-	response := ctx.GetStub().InvokeChaincode("inventory-sc", queryArgs, "mychannel")
-	if response.Status != shim.OK {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", response.Payload)
-	}
+func (s *SmartContract) GetAssetsByLabels(ctx contractapi.TransactionContextInterface, labelSelector string) ([]model.Asset, error) {
+	return queryInventory(ctx, "GetAssetsByLabels", labelSelector)
+}
 
-	assetArray, err := internal.JsonToAssetArray(string(response.GetPayload()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", err)
-	}
-	return assetArray, nil
+// PAGINATED INVENTORY QUERIES, THE BOOKMARK IS PASSED THROUGH TO THE INVENTORY SMART CONTRACT
+func (s *SmartContract) GetServerAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return queryInventoryPage(ctx, "GetServerAssetsPage", pageSize, bookmark)
 }
 
-func (s *SmartContract) GetSensorAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]internal.Asset, error) {
-	params := []string{"GetSensorAssetsExceptId", excludeId}
-	queryArgs := make([][]byte, len(params))
-	for i, arg := range params {
-		queryArgs[i] = []byte(arg)
-	}
+func (s *SmartContract) GetServerAssetsExceptIdPage(ctx contractapi.TransactionContextInterface, excludeId string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return queryInventoryPage(ctx, "GetServerAssetsExceptIdPage", pageSize, bookmark, excludeId)
+}
 
-	response := ctx.GetStub().InvokeChaincode("inventory-sc", queryArgs, "mychannel")
-	if response.Status != shim.OK {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", response.Payload)
-	}
+func (s *SmartContract) GetRobotAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return queryInventoryPage(ctx, "GetRobotAssetsPage", pageSize, bookmark)
+}
 
-	assetArray, err := internal.JsonToAssetArray(string(response.GetPayload()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", err)
+func (s *SmartContract) GetRobotAssetsExceptIdPage(ctx contractapi.TransactionContextInterface, excludeId string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return queryInventoryPage(ctx, "GetRobotAssetsExceptIdPage", pageSize, bookmark, excludeId)
+}
+
+func (s *SmartContract) GetSensorAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return queryInventoryPage(ctx, "GetSensorAssetsPage", pageSize, bookmark)
+}
+
+func (s *SmartContract) GetSensorAssetsExceptIdPage(ctx contractapi.TransactionContextInterface, excludeId string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return queryInventoryPage(ctx, "GetSensorAssetsExceptIdPage", pageSize, bookmark, excludeId)
+}
+
+func (s *SmartContract) GetSensorAndRobotAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return queryInventoryPage(ctx, "GetSensorAndRobotAssetsPage", pageSize, bookmark)
+}
+
+func (s *SmartContract) GetSensorAndRobotAssetsExceptIdPage(ctx contractapi.TransactionContextInterface, excludeId string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return queryInventoryPage(ctx, "GetSensorAndRobotAssetsExceptIdPage", pageSize, bookmark, excludeId)
+}
+
+func (s *SmartContract) GetAssetsByTypePage(ctx contractapi.TransactionContextInterface, typeName string, excludeIds []string, filtersJson string, labelSelector string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return queryInventoryPage(ctx, "GetAssetsByTypePage", pageSize, bookmark, typeName, idsToJson(excludeIds), filtersJson, labelSelector)
+}
+
+func (s *SmartContract) GetAssetsByLabelsPage(ctx contractapi.TransactionContextInterface, labelSelector string, pageSize int32, bookmark string) (model.AssetPage, error) {
+	return queryInventoryPage(ctx, "GetAssetsByLabelsPage", pageSize, bookmark, labelSelector)
+}
+
+// idsToJson encodes a list argument of the inventory, nil is sent as []
+func idsToJson(ids []string) string {
+	if ids == nil {
+		ids = []string{}
 	}
-	return assetArray, nil
+	s, _ := json.Marshal(ids)
+	return string(s)
 }
 
-func (s *SmartContract) GetSensorAndRobotAssets(ctx contractapi.TransactionContextInterface) ([]internal.Asset, error) {
-	params := []string{"GetSensorAndRobotAssets"}
-	queryArgs := make([][]byte, len(params))
-	for i, arg := range params {
-		queryArgs[i] = []byte(arg)
+// initConfig stores the default configuration unless one was already set
+func initConfig(ctx contractapi.TransactionContextInterface) error {
+	config, err := invoke.GetConfig(ctx.GetStub())
+	if err != nil {
+		return err
 	}
+	_, err = invoke.PutConfig(ctx.GetStub(), config)
+	return err
+}
 
-	response := ctx.GetStub().InvokeChaincode("inventory-sc", queryArgs, "mychannel")
-	if response.Status != shim.OK {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", response.Payload)
+func queryInventory(ctx contractapi.TransactionContextInterface, function string, args ...string) ([]model.Asset, error) {
+	config, err := invoke.GetConfig(ctx.GetStub())
+	if err != nil {
+		return nil, err
 	}
 
-	assetArray, err := internal.JsonToAssetArray(string(response.GetPayload()))
+	assetArray := []model.Asset{}
+	err = config.InventoryChaincode().Query(ctx.GetStub(), &assetArray, function, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", err)
+		return nil, err
 	}
 	return assetArray, nil
 }
 
-func (s *SmartContract) GetSensorAndRobotAssetsExceptId(ctx contractapi.TransactionContextInterface, excludeId string) ([]internal.Asset, error) {
-	params := []string{"GetSensorAndRobotAssetsExceptId", excludeId}
-	queryArgs := make([][]byte, len(params))
-	for i, arg := range params {
-		queryArgs[i] = []byte(arg)
-	}
-
-	response := ctx.GetStub().InvokeChaincode("inventory-sc", queryArgs, "mychannel")
-	if response.Status != shim.OK {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", response.Payload)
+// queryInventoryPage invokes a paginated query of the inventory, args come before the page size and bookmark
+func queryInventoryPage(ctx contractapi.TransactionContextInterface, function string, pageSize int32, bookmark string, args ...string) (model.AssetPage, error) {
+	config, err := invoke.GetConfig(ctx.GetStub())
+	if err != nil {
+		return model.AssetPage{}, err
 	}
 
-	assetArray, err := internal.JsonToAssetArray(string(response.GetPayload()))
+	var assetPage model.AssetPage
+	args = append(args, strconv.FormatInt(int64(pageSize), 10), bookmark)
+	err = config.InventoryChaincode().Query(ctx.GetStub(), &assetPage, function, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query chaincode. Error %s", err)
+		return model.AssetPage{}, err
 	}
-	return assetArray, nil
+	return model.CreateAssetPage(assetPage.Records, assetPage.FetchedCount, assetPage.Bookmark), nil
 }
 
-// func iteratorSlicerAsset(resultsIterator shim.StateQueryIteratorInterface) ([]internal.Asset, error) {
-// 	var assets []internal.Asset
+// func iteratorSlicerAsset(resultsIterator shim.StateQueryIteratorInterface) ([]model.Asset, error) {
+// 	var assets []model.Asset
 // 	for resultsIterator.HasNext() {
 // 		queryResponse, err := resultsIterator.Next()
 // 		if err != nil {
@@ -446,22 +784,18 @@ func (s *SmartContract) GetSensorAndRobotAssetsExceptId(ctx contractapi.Transact
 
 // Function for testing CouchDB Queries
 func (s *SmartContract) ExecuteQuery(ctx contractapi.TransactionContextInterface, assetQuery string) ([]string, error) {
-	var result []string
+	result := []string{}
 	resultsIterator, err := ctx.GetStub().GetQueryResult(assetQuery)
 	if err != nil {
-		return result, err
+		return nil, errs.InvalidArgumentf("failed to execute query: %v", err)
 	}
 	defer resultsIterator.Close()
-	if resultsIterator.HasNext() {
-		for resultsIterator.HasNext() {
-			queryResponse, err := resultsIterator.Next()
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, string(queryResponse.Value))
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, errs.Internalf("failed to read query results: %v", err)
 		}
-	} else {
-		return nil, fmt.Errorf("failed to query chaincode. No results found for iterator")
+		result = append(result, string(queryResponse.Value))
 	}
 
 	return result, nil
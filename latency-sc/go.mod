@@ -3,6 +3,7 @@ module github.com/dmonteroh/distributed-resources-smartcontract/latency-sc
 go 1.17
 
 require (
+	github.com/dmonteroh/distributed-resources-smartcontract/common v0.0.0
 	github.com/hyperledger/fabric-chaincode-go v0.0.0-20200424173110-d7076418f212
 	github.com/hyperledger/fabric-contract-api-go v1.1.1
 	github.com/wI2L/jettison v0.7.3
@@ -33,3 +34,5 @@ require (
 	google.golang.org/grpc v1.23.0 // indirect
 	gopkg.in/yaml.v2 v2.2.8 // indirect
 )
+
+replace github.com/dmonteroh/distributed-resources-smartcontract/common => ../common
@@ -2,8 +2,10 @@ package internal
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
 	"github.com/wI2L/jettison"
 )
 
@@ -14,11 +16,12 @@ type LatencyTargets struct {
 	Targets []LatencyTarget `json:"targets"`
 }
 
+// Credentials of the target are not sent to the latency collector, the owner organization reads them
+// with GetAssetCredentials of the inventory Smart Contract and checks them against the hash
 type LatencyTarget struct {
-	Hostname     string `json:"hostname"`
-	Hostport     string `json:"hostPort"`
-	HostUser     string `json:"hostUser"`
-	HostPassword string `json:"hostPassword"`
+	Hostname        string `json:"hostname"`
+	Hostport        string `json:"hostPort"`
+	CredentialsHash string `json:"credentialsHash"`
 }
 
 func LatencyJsonToStrcut(v string) (targets LatencyTargets, err error) {
@@ -26,23 +29,11 @@ func LatencyJsonToStrcut(v string) (targets LatencyTargets, err error) {
 	return targets, err
 }
 
-// -- TIMESTAMP
-type LatencyTimestamp struct {
-	TimeLocal   time.Time `json:"timeLocal"`
-	TimeSeconds int64     `json:"timeSeconds"`
-	TimeNano    int64     `json:"timeNano"`
-}
-
-func (d LatencyTimestamp) String() string {
-	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
-	return string(s)
-}
-
 // Latency Results
 type LatencyResults struct {
-	Source    string           `json:"source"`
-	Timestamp LatencyTimestamp `json:"timestamp"`
-	Results   []LatencyResult  `json:"results"`
+	Source    string          `json:"source"`
+	Timestamp model.Timestamp `json:"timestamp"`
+	Results   []LatencyResult `json:"results"`
 }
 
 func LatencyResultsJsonToStruct(v string) (targets LatencyResults, err error) {
@@ -71,10 +62,10 @@ func LatencyJsonToStruct(v string) (targets LatencyTargets, err error) {
 }
 
 type LatencyAsset struct {
-	ID        string           `json:"id"`
-	Source    string           `json:"source"`
-	Timestamp LatencyTimestamp `json:"timestamp"`
-	Results   []LatencyResult  `json:"results"`
+	ID        string          `json:"id"`
+	Source    string          `json:"source"`
+	Timestamp model.Timestamp `json:"timestamp"`
+	Results   []LatencyResult `json:"results"`
 }
 
 func (d LatencyAsset) String() string {
@@ -96,44 +87,64 @@ func CreateLatencyAsset(id string, latencyResults LatencyResults) LatencyAsset {
 	}
 }
 
-func CreateLatencyID(appType string, source string, timestamp LatencyTimestamp) string {
-	if appType == "single_insert" {
-		return source + "-" + DateFormatID(timestamp.TimeSeconds)
-	} else if appType == "single_upsert" {
-		return source
-	} else {
-		panic("APP_TYPE not implemented")
-	}
+// -- PAGINATION
+// Page of latency assets, the bookmark is sent back to fetch the next page
+type LatencyAssetPage struct {
+	Records      []LatencyAsset `json:"records"`
+	FetchedCount int32          `json:"fetchedCount"`
+	Bookmark     string         `json:"bookmark"`
 }
 
-func DateFormatID(d int64) string {
-	t := time.Unix(d, 0)
-	layout := "2006-01-02T15:04:05"
-	return t.Format(layout)
+func CreateLatencyAssetPage(records []LatencyAsset, fetchedCount int32, bookmark string) LatencyAssetPage {
+	if records == nil {
+		records = []LatencyAsset{}
+	}
+	return LatencyAssetPage{Records: records, FetchedCount: fetchedCount, Bookmark: bookmark}
 }
 
-/////////////////////
+// Storage modes of the latency results:
+// single_insert keeps every measurement in the time series of the source (common/series) under the composite key
+// (latencySample, source, time), single_upsert keeps only the latest one under source.
+// The measurements of the time series are indexed by target under (latencyTarget, target, time, source), the value being the key of the measurement
+const (
+	ModeSingleInsert = "single_insert"
+	ModeSingleUpsert = "single_upsert"
 
-type LatencyAnalysis struct {
-	Hostname       string  `json:"hostname"`
-	Target         string  `json:"target"`
-	Duration       int     `json:"duration"`
-	AverageLatency float64 `json:"averageLatency"`
-	LatencyCount   int     `json:"latencyCount"`
-	LatencySummary []int64 `json:"statSummary"`
-}
+	LatencyObjectType       = "latencySample"
+	LatencyTargetObjectType = "latencyTarget"
 
-func (d LatencyAnalysis) String() string {
-	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
-	return string(s)
+	// Indexes of the assets stored under a single key, by source and by target
+	LatencyLatestObjectType       = "latencyLatest"
+	LatencyLatestTargetObjectType = "latencyLatestTarget"
+
+	dateLayoutID = "2006-01-02T15:04:05.000000000"
+)
+
+var LatencyModes = []string{ModeSingleInsert, ModeSingleUpsert}
+
+func CreateLatencyID(appType string, source string, timestamp model.Timestamp) (string, error) {
+	if appType == ModeSingleInsert {
+		return source + "-" + DateFormatID(timestamp), nil
+	} else if appType == ModeSingleUpsert {
+		return source, nil
+	} else {
+		return "", fmt.Errorf("unsupported mode %q, expected any of %v", appType, LatencyModes)
+	}
 }
 
-func LatencyAnalysisJsonToStruct(v string) (asset LatencyAnalysis, err error) {
-	err = json.Unmarshal([]byte(v), &asset)
-	return asset, err
+// DateFormatID formats the timestamp in UTC with nanoseconds, every peer derives the same ID
+// and samples taken in the same second get different IDs, as their series keys
+func DateFormatID(timestamp model.Timestamp) string {
+	t := time.Unix(timestamp.TimeSeconds, 0)
+	if timestamp.TimeNano != 0 {
+		t = time.Unix(0, timestamp.TimeNano)
+	}
+	return t.UTC().Format(dateLayoutID)
 }
 
-func AnalizeLatencySummary(latencyAnalysis LatencyAnalysis) LatencyAnalysis {
+/////////////////////
+
+func AnalizeLatencySummary(latencyAnalysis model.LatencyAnalysis) model.LatencyAnalysis {
 	if len(latencyAnalysis.LatencySummary) > 0 {
 		var AverageLatency float64 = 0
 		for _, summary := range latencyAnalysis.LatencySummary {
@@ -0,0 +1,32 @@
+package internal
+
+import (
+	"math"
+	"time"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/validation"
+)
+
+// ValidateLatencyResults adds the violations of the results to the report. When now is set the timestamp
+// must be within validation.MaxClockSkew of it. A latency of -1 means the target could not be reached
+func ValidateLatencyResults(report *validation.Report, results LatencyResults, now time.Time) {
+	report.Required("source", results.Source)
+	report.Timestamp("timestamp", results.Timestamp, now)
+	if len(results.Results) == 0 {
+		report.Add("results", validation.RuleRequired, "no latency results were posted")
+	}
+
+	seen := make(map[string]int)
+	for i, result := range results.Results {
+		report.Required(validation.Index("results", i, "hostname"), result.Hostname)
+		report.Range(validation.Index("results", i, "latency"), float64(result.Latency), -1, math.Inf(1))
+		if result.Hostname == "" {
+			continue
+		}
+		if first, ok := seen[result.Hostname]; ok {
+			report.Add(validation.Index("results", i, "hostname"), validation.RuleUnique, "%s was already measured in results[%d]", result.Hostname, first)
+			continue
+		}
+		seen[result.Hostname] = i
+	}
+}
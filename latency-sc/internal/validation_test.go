@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/validation"
+)
+
+var now = time.Unix(1700000000, 0)
+
+func validResults() LatencyResults {
+	return LatencyResults{
+		Source:    "host-1",
+		Timestamp: model.Timestamp{TimeLocal: now, TimeSeconds: now.Unix(), TimeNano: now.UnixNano()},
+		Results:   []LatencyResult{{Hostname: "host-2", Latency: 12}, {Hostname: "host-3", Latency: -1}, {Hostname: "host-4", Latency: 0}},
+	}
+}
+
+func TestValidateLatencyResults(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(r *LatencyResults)
+		now   time.Time
+		field string
+		rule  string
+	}{
+		{"valid", func(r *LatencyResults) {}, now, "", ""},
+		{"no transaction timestamp", func(r *LatencyResults) {
+			r.Timestamp = model.Timestamp{TimeSeconds: now.Add(-time.Hour).Unix()}
+		}, time.Time{}, "", ""},
+
+		{"source", func(r *LatencyResults) { r.Source = "" }, now, "source", validation.RuleRequired},
+		{"timestamp", func(r *LatencyResults) { r.Timestamp = model.Timestamp{} }, now, "timestamp", validation.RuleRequired},
+		{"clock skew", func(r *LatencyResults) {
+			r.Timestamp = model.Timestamp{TimeSeconds: now.Add(-validation.MaxClockSkew - time.Second).Unix()}
+		}, now, "timestamp", validation.RuleSkew},
+		{"results", func(r *LatencyResults) { r.Results = nil }, now, "results", validation.RuleRequired},
+		{"target hostname", func(r *LatencyResults) { r.Results[1].Hostname = "" }, now, "results[1].hostname", validation.RuleRequired},
+		{"latency", func(r *LatencyResults) { r.Results[0].Latency = -2 }, now, "results[0].latency", validation.RuleRange},
+		{"duplicated target", func(r *LatencyResults) { r.Results[2].Hostname = "host-2" }, now, "results[2].hostname", validation.RuleUnique},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := validResults()
+			tt.edit(&results)
+			report := validation.NewReport()
+			ValidateLatencyResults(&report, results, tt.now)
+			if tt.field == "" {
+				if !report.Valid {
+					t.Errorf("violations = %v, want none", report.Violations)
+				}
+				return
+			}
+			if report.Valid || len(report.Violations) != 1 {
+				t.Fatalf("violations = %v, want one on %s", report.Violations, tt.field)
+			}
+			violation := report.Violations[0]
+			if violation.Field != tt.field || violation.Rule != tt.rule {
+				t.Errorf("violation = %s %s, want %s %s", violation.Field, violation.Rule, tt.field, tt.rule)
+			}
+			if violation.Message == "" {
+				t.Errorf("violation of %s has no message", violation.Field)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/offload"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/series"
+	"github.com/dmonteroh/distributed-resources-smartcontract/resources-sc/internal"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// IngestOffloadedStats anchors a DrcStats payload kept in a content-addressed store (common/offload).
+// The collector uploads the payload, then sends the same bytes in the transient map under the stats key and the URI returned by the store.
+// Only the summary, hash, size and URI of the payload are written to the ledger
+func (s *SmartContract) IngestOffloadedStats(ctx contractapi.TransactionContextInterface, uri string) (internal.OffloadedStat, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return internal.OffloadedStat{}, errs.Internalf("failed to read the transient map: %v", err)
+	}
+	payload, found := transient[internal.StatsTransientKey]
+	if !found {
+		return internal.OffloadedStat{}, errs.InvalidArgumentf("the offloaded stats must be sent in the transient map under the key %s", internal.StatsTransientKey)
+	}
+	drcStats, mspID, err := jsonToValidStats(ctx, string(payload))
+	if err != nil {
+		return internal.OffloadedStat{}, err
+	}
+	contentHash := offload.Hash(payload)
+	uriHash, err := offload.HashFromURI(uri)
+	if err != nil {
+		return internal.OffloadedStat{}, errs.InvalidArgumentf("%v", err)
+	}
+	if uriHash != contentHash {
+		return internal.OffloadedStat{}, errs.InvalidArgumentf("the URI %s does not address the posted stats, their hash is %s", uri, contentHash)
+	}
+
+	statID, err := internal.CreateStatID(internal.StorageOffloaded, drcStats.DrcHost.Hostname, drcStats.Timestamp)
+	if err != nil {
+		return internal.OffloadedStat{}, errs.InvalidArgumentf("%v", err)
+	}
+	key, err := series.Key(ctx.GetStub(), internal.OffloadedStatObjectType, drcStats.DrcHost.Hostname, drcStats.Timestamp)
+	if err != nil {
+		return internal.OffloadedStat{}, err
+	}
+	exists, err := s.AssetExists(ctx, key)
+	if err != nil {
+		return internal.OffloadedStat{}, err
+	}
+	if exists {
+		return internal.OffloadedStat{}, errs.AlreadyExistsf("the Stats for %s already exists", statID)
+	}
+
+	offloadedStat := internal.OffloadedStat{
+		ID:          statID,
+		Hostname:    drcStats.DrcHost.Hostname,
+		SubmittedBy: mspID,
+		Timestamp:   drcStats.Timestamp,
+		BootTime:    drcStats.DrcHost.BootTime,
+		Summary:     internal.SummarizeStoredStat(internal.ConvertToStorage(statID, drcStats)),
+		ContentHash: contentHash,
+		Size:        int64(len(payload)),
+		URI:         uri,
+	}
+	return offloadedStat, errs.Wrap(errs.Internal, ctx.GetStub().PutState(key, []byte(offloadedStat.String())))
+}
+
+func (s *SmartContract) GetOffloadedStat(ctx contractapi.TransactionContextInterface, statID string) (internal.OffloadedStat, error) {
+	return readOffloadedStat(ctx, statID)
+}
+
+// GetLastOffloadedStats returns the n latest offloaded stats of the host, newest first
+func (s *SmartContract) GetLastOffloadedStats(ctx contractapi.TransactionContextInterface, hostname string, n int) ([]internal.OffloadedStat, error) {
+	samples, err := series.Last(ctx.GetStub(), internal.OffloadedStatObjectType, hostname, n)
+	if err != nil {
+		return nil, err
+	}
+	return samplesToOffloadedStats(samples)
+}
+
+// offloadedSummariesBetween returns the summaries of the offloaded stats of the host with fromSeconds <= timestamp < toSeconds, oldest first
+func offloadedSummariesBetween(ctx contractapi.TransactionContextInterface, hostname string, fromSeconds int64, toSeconds int64) ([]model.StatSummary, error) {
+	samples, err := series.Between(ctx.GetStub(), internal.OffloadedStatObjectType, hostname, fromSeconds, toSeconds)
+	if err != nil {
+		return nil, err
+	}
+	offloadedStats, err := samplesToOffloadedStats(samples)
+	if err != nil {
+		return nil, err
+	}
+	summaries := []model.StatSummary{}
+	for _, offloadedStat := range offloadedStats {
+		summaries = append(summaries, offloadedStat.Summary)
+	}
+	return summaries, nil
+}
+
+func samplesToOffloadedStats(samples []string) ([]internal.OffloadedStat, error) {
+	offloadedStats := []internal.OffloadedStat{}
+	for _, sample := range samples {
+		offloadedStat, err := internal.JsonToOffloadedStat(sample)
+		if err != nil {
+			return nil, errs.Internalf("failed to decode the offloaded Stats. %v", err)
+		}
+		offloadedStats = append(offloadedStats, offloadedStat)
+	}
+	return offloadedStats, nil
+}
+
+// VerifyOffloadedStat checks a payload read from the store against the hash and size anchored in the ledger.
+// A payload that does not match returns valid=false and the reason, not an error
+func (s *SmartContract) VerifyOffloadedStat(ctx contractapi.TransactionContextInterface, statID string, payload string) (internal.OffloadVerification, error) {
+	offloadedStat, err := readOffloadedStat(ctx, statID)
+	if err != nil {
+		return internal.OffloadVerification{}, err
+	}
+	verification := internal.OffloadVerification{
+		ID:           statID,
+		ContentHash:  offloadedStat.ContentHash,
+		ComputedHash: offload.Hash([]byte(payload)),
+		Size:         offloadedStat.Size,
+		ComputedSize: int64(len(payload)),
+		URI:          offloadedStat.URI,
+	}
+	switch {
+	case verification.ComputedHash != verification.ContentHash:
+		verification.Reason = "the hash of the payload does not match the anchored hash"
+	case verification.ComputedSize != verification.Size:
+		verification.Reason = fmt.Sprintf("the payload has %d bytes, %d were anchored", verification.ComputedSize, verification.Size)
+	default:
+		verification.Valid = true
+	}
+	return verification, nil
+}
+
+func readOffloadedStat(ctx contractapi.TransactionContextInterface, statID string) (internal.OffloadedStat, error) {
+	hostname, timestamp, err := internal.ParseStatID(statID)
+	if err != nil {
+		return internal.OffloadedStat{}, errs.InvalidArgumentf("%v", err)
+	}
+	key, err := series.Key(ctx.GetStub(), internal.OffloadedStatObjectType, hostname, timestamp)
+	if err != nil {
+		return internal.OffloadedStat{}, err
+	}
+	offloadedJson, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return internal.OffloadedStat{}, errs.Internalf("failed to read from world state: %v", err)
+	}
+	if offloadedJson == nil {
+		return internal.OffloadedStat{}, errs.NotFoundf("the offloaded Stats %s do not exist", statID)
+	}
+	offloadedStat, err := internal.JsonToOffloadedStat(string(offloadedJson))
+	if err != nil {
+		return internal.OffloadedStat{}, errs.Internalf("failed to decode the offloaded Stats %s. %v", statID, err)
+	}
+	return offloadedStat, nil
+}
@@ -0,0 +1,101 @@
+package chaincode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/offload"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/series"
+	"github.com/dmonteroh/distributed-resources-smartcontract/resources-sc/internal"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func offloadedAt() model.Timestamp {
+	at := time.Unix(1700000000, 0).UTC()
+	return model.Timestamp{TimeLocal: at, TimeSeconds: at.Unix(), TimeNano: at.UnixNano()}
+}
+
+// anchorOffloadedStat writes the offloaded stat of payload as IngestOffloadedStats would
+func anchorOffloadedStat(t *testing.T, ctx *contractapi.TransactionContext, payload string) string {
+	timestamp := offloadedAt()
+	statID, err := internal.CreateStatID(internal.StorageOffloaded, "host-1", timestamp)
+	if err != nil {
+		t.Fatalf("CreateStatID: %v", err)
+	}
+	key, err := series.Key(ctx.GetStub(), internal.OffloadedStatObjectType, "host-1", timestamp)
+	if err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	offloadedStat := internal.OffloadedStat{
+		ID:          statID,
+		Hostname:    "host-1",
+		Timestamp:   timestamp,
+		ContentHash: offload.Hash([]byte(payload)),
+		Size:        int64(len(payload)),
+		URI:         "s3://stats/sha256/" + offload.Hash([]byte(payload)),
+	}
+	stub := ctx.GetStub().(*shimtest.MockStub)
+	stub.MockTransactionStart("anchor")
+	defer stub.MockTransactionEnd("anchor")
+	if err := stub.PutState(key, []byte(offloadedStat.String())); err != nil {
+		t.Fatalf("PutState: %v", err)
+	}
+	return statID
+}
+
+func TestVerifyOffloadedStat(t *testing.T) {
+	payload := `{"host":{"hostname":"host-1"},"cpuStats":{"averageUsage":10}}`
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(shimtest.NewMockStub("resources", nil))
+	statID := anchorOffloadedStat(t, ctx, payload)
+
+	tests := []struct {
+		name    string
+		payload string
+		valid   bool
+	}{
+		{"anchored payload", payload, true},
+		{"tampered payload", `{"host":{"hostname":"host-1"},"cpuStats":{"averageUsage":90}}`, false},
+		{"truncated payload", payload[:len(payload)-1], false},
+		{"empty payload", "", false},
+	}
+	contract := new(SmartContract)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verification, err := contract.VerifyOffloadedStat(ctx, statID, tt.payload)
+			if err != nil {
+				t.Fatalf("VerifyOffloadedStat: %v", err)
+			}
+			if verification.Valid != tt.valid {
+				t.Errorf("valid = %v, want %v (%s)", verification.Valid, tt.valid, verification.Reason)
+			}
+			if !tt.valid && verification.Reason == "" {
+				t.Errorf("the rejected payload has no reason")
+			}
+			if verification.ComputedHash != offload.Hash([]byte(tt.payload)) {
+				t.Errorf("computed hash = %s, want the hash of the payload", verification.ComputedHash)
+			}
+		})
+	}
+}
+
+func TestVerifyOffloadedStatUnknown(t *testing.T) {
+	ctx := &contractapi.TransactionContext{}
+	ctx.SetStub(shimtest.NewMockStub("resources", nil))
+	contract := new(SmartContract)
+	tests := []struct {
+		statID string
+		code   errs.Code
+	}{
+		{"host-1-" + internal.DateFormatID(offloadedAt()), errs.NotFound},
+		{"host-1", errs.InvalidArgument},
+	}
+	for _, tt := range tests {
+		if _, err := contract.VerifyOffloadedStat(ctx, tt.statID, "{}"); err == nil || errs.CodeOf(err) != tt.code {
+			t.Errorf("VerifyOffloadedStat(%s) = %v, want %s", tt.statID, err, tt.code)
+		}
+	}
+}
@@ -2,10 +2,15 @@ package chaincode
 
 import (
 	"encoding/json"
-	"fmt"
 	"sort"
-	"time"
 
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/access"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/clock"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/invoke"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/mango"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/series"
 	"github.com/dmonteroh/distributed-resources-smartcontract/resources-sc/internal"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
@@ -23,70 +28,139 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 	for _, stat := range stats {
 		err := ctx.GetStub().PutState(stat.DrcHost.HostID, []byte(stat.String()))
 		if err != nil {
-			return fmt.Errorf("failed to put to world state. %v", err)
+			return errs.Internalf("failed to put to world state. %v", err)
 		}
 	}
-	return nil
+	return initConfig(ctx)
 }
 
-// CreateAsset issues a new asset to the world state with given details.
-func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, statIP string, statJSON string) error {
-	exists, err := s.AssetExists(ctx, statIP)
+// IngestStats stores the DrcStats posted by a resource collector, the key is derived from the storage mode:
+// unique adds the stat to the time series of the host and updatable creates or replaces the latest stat of the host under hostname
+func (s *SmartContract) IngestStats(ctx contractapi.TransactionContextInterface, statsJson string, mode string) (internal.StoredStat, error) {
+	drcStats, mspID, err := jsonToValidStats(ctx, statsJson)
 	if err != nil {
-		return err
+		return internal.StoredStat{}, err
+	}
+	statID, err := internal.CreateStatID(mode, drcStats.DrcHost.Hostname, drcStats.Timestamp)
+	if err != nil {
+		return internal.StoredStat{}, errs.InvalidArgumentf("%v", err)
+	}
+	if mode == internal.StorageUpdatable {
+		return putStats(ctx, statID, statID, drcStats, mspID)
+	}
+	if mode == internal.StorageOffloaded {
+		return internal.StoredStat{}, errs.InvalidArgumentf("offloaded stats are anchored with IngestOffloadedStats, the transaction arguments are stored in the blocks")
+	}
+
+	key, err := series.Key(ctx.GetStub(), internal.StatObjectType, drcStats.DrcHost.Hostname, drcStats.Timestamp)
+	if err != nil {
+		return internal.StoredStat{}, err
+	}
+	exists, err := s.AssetExists(ctx, key)
+	if err != nil {
+		return internal.StoredStat{}, err
 	}
 	if exists {
-		return fmt.Errorf("the Stats for %s already exists", statIP)
+		return internal.StoredStat{}, errs.AlreadyExistsf("the Stats for %s already exists", statID)
 	}
+	return putStats(ctx, key, statID, drcStats, mspID)
+}
 
-	toStore, err := internal.JsonToStoredStat(statJSON)
+// GetLastResources returns the n latest stats of the time series of the host, newest first
+func (s *SmartContract) GetLastResources(ctx contractapi.TransactionContextInterface, hostname string, n int) ([]internal.StoredStat, error) {
+	samples, err := series.Last(ctx.GetStub(), internal.StatObjectType, hostname, n)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return samplesToStats(samples)
+}
+
+// GetResourcesBetween returns the stats of the time series of the host with fromSeconds <= timestamp < toSeconds (unix seconds), newest first
+func (s *SmartContract) GetResourcesBetween(ctx contractapi.TransactionContextInterface, hostname string, fromSeconds int64, toSeconds int64) ([]internal.StoredStat, error) {
+	samples, err := series.Between(ctx.GetStub(), internal.StatObjectType, hostname, fromSeconds, toSeconds)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := samplesToStats(samples)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(stats)-1; i < j; i, j = i+1, j-1 {
+		stats[i], stats[j] = stats[j], stats[i]
+	}
+	return stats, nil
+}
+
+func samplesToStats(samples []string) ([]internal.StoredStat, error) {
+	stats := []internal.StoredStat{}
+	for _, sample := range samples {
+		stat, err := internal.JsonToStoredStat(sample)
+		if err != nil {
+			return nil, errs.Internalf("failed to decode the Stats. %v", err)
+		}
+		stats = append(stats, stat)
 	}
-	// toStore := internal.ConvertToStorage(tmpStat)
-	// toStore.ID = statIP
-	// RUN VALIDATION
+	return stats, nil
+}
 
-	return ctx.GetStub().PutState(statIP, []byte(toStore.String()))
+// CreateAsset adds the stats to the time series of the host, as IngestStats in unique mode.
+// Kept for older collectors, statIP must be the host.hostname of the payload, the key is derived from it
+func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, statIP string, statJSON string) error {
+	err := assertStatIP(statIP, statJSON)
+	if err != nil {
+		return err
+	}
+	_, err = s.IngestStats(ctx, statJSON, internal.StorageUnique)
+	return err
 }
 
 // ReadAsset returns the asset stored in the world state with given id.
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, statIP string) (*internal.StoredStat, error) {
 	statJSON, err := ctx.GetStub().GetState(statIP)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read from world state: %v", err)
+		return nil, errs.Internalf("failed to read from world state: %v", err)
 	}
 	if statJSON == nil {
-		return nil, fmt.Errorf("the Stats for %s do not exist", statIP)
+		return nil, errs.NotFoundf("the Stats for %s do not exist", statIP)
 	}
 
 	var stat internal.StoredStat
 	err = json.Unmarshal(statJSON, &stat)
 	if err != nil {
-		return nil, err
+		return nil, errs.Internalf("failed to decode the Stats for %s. %v", statIP, err)
 	}
 
 	return &stat, nil
 }
 
-// UpdateAsset updates an existing asset in the world state with provided parameters.
+// UpdateAsset replaces the existing updatable stats of the host, as IngestStats in updatable mode.
+// Kept for older collectors, statIP must be the host.hostname of the payload, stats that were never created return NOT_FOUND
 func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, statIP string, statJSON string) error {
+	err := assertStatIP(statIP, statJSON)
+	if err != nil {
+		return err
+	}
 	exists, err := s.AssetExists(ctx, statIP)
 	if err != nil {
 		return err
 	}
 	if !exists {
-		return fmt.Errorf("the Stats for %s do not exist", statIP)
+		return errs.NotFoundf("the Stats for %s do not exist", statIP)
 	}
+	_, err = s.IngestStats(ctx, statJSON, internal.StorageUpdatable)
+	return err
+}
 
-	tmpStat, err := internal.DrcJsonToStruct(statJSON)
+// assertStatIP checks that statIP, the key sent by older collectors, is the host.hostname of the stats
+func assertStatIP(statIP string, statJSON string) error {
+	drcStats, err := internal.DrcJsonToStruct(statJSON)
 	if err != nil {
-		return err
+		return errs.InvalidArgumentf("invalid stats: %v", err)
 	}
-	toStore := internal.ConvertToStorage(tmpStat)
-	toStore.ID = statIP
-
-	return ctx.GetStub().PutState(statIP, []byte(toStore.String()))
+	if statIP == "" || drcStats.DrcHost.Hostname != statIP {
+		return errs.InvalidArgumentf("the stats of %q were posted for %q, statIP must be host.hostname", drcStats.DrcHost.Hostname, statIP)
+	}
+	return nil
 }
 
 // DeleteAsset deletes an given asset from the world state.
@@ -96,38 +170,69 @@ func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface,
 		return err
 	}
 	if !exists {
-		return fmt.Errorf("the Stats for %s do not exist", statIP)
+		return errs.NotFoundf("the Stats for %s do not exist", statIP)
+	}
+
+	return errs.Wrap(errs.Internal, ctx.GetStub().DelState(statIP))
+}
+
+// jsonToValidStats decodes the DrcStats posted by a collector and returns them with the MSP ID of the client.
+// Clients bound to a host (certificate attribute hostname) can only post its stats, the values are checked by validateStats
+func jsonToValidStats(ctx contractapi.TransactionContextInterface, statsJson string) (internal.DrcStats, string, error) {
+	drcStats, err := internal.DrcJsonToStruct(statsJson)
+	if err != nil {
+		return internal.DrcStats{}, "", errs.InvalidArgumentf("invalid stats: %v", err)
+	}
+	err = access.AssertHostname(ctx.GetClientIdentity(), drcStats.DrcHost.Hostname)
+	if err != nil {
+		return internal.DrcStats{}, "", err
 	}
 
-	return ctx.GetStub().DelState(statIP)
+	// RUN VALIDATIONS
+	report, err := validateStats(ctx, drcStats)
+	if err != nil {
+		return internal.DrcStats{}, "", err
+	}
+	err = report.Err("stats")
+	if err != nil {
+		return internal.DrcStats{}, "", err
+	}
+	mspID, err := access.MSPID(ctx.GetClientIdentity())
+	if err != nil {
+		return internal.DrcStats{}, "", err
+	}
+	return drcStats, mspID, nil
+}
+
+func putStats(ctx contractapi.TransactionContextInterface, key string, statID string, drcStats internal.DrcStats, mspID string) (internal.StoredStat, error) {
+	toStore := internal.ConvertToStorage(statID, drcStats)
+	toStore.SubmittedBy = mspID
+	return toStore, errs.Wrap(errs.Internal, ctx.GetStub().PutState(key, []byte(toStore.String())))
 }
 
 // AssetExists returns true when asset with given ID exists in world state
 func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface, statIP string) (bool, error) {
 	statJSON, err := ctx.GetStub().GetState(statIP)
 	if err != nil {
-		return false, fmt.Errorf("failed to read from world state: %v", err)
+		return false, errs.Internalf("failed to read from world state: %v", err)
 	}
 
 	return statJSON != nil, nil
 }
 
+// iteratorSlicer reads every stat of the iterator, newest first. Queries without results return an empty slice
 func iteratorSlicer(resultsIterator shim.StateQueryIteratorInterface) ([]internal.StoredStat, error) {
-	var assets []internal.StoredStat
-	if resultsIterator.HasNext() {
-		for resultsIterator.HasNext() {
-			queryResponse, err := resultsIterator.Next()
-			if err != nil {
-				return nil, err
-			}
-			asset, err := internal.JsonToStoredStat(string(queryResponse.Value))
-			if err != nil {
-				return nil, err
-			}
-			assets = append(assets, asset)
+	assets := []internal.StoredStat{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, errs.Internalf("failed to read query results: %v", err)
+		}
+		asset, err := internal.JsonToStoredStat(string(queryResponse.Value))
+		if err != nil {
+			return nil, errs.Internalf("failed to decode the Stats for %s. %v", queryResponse.Key, err)
 		}
-	} else {
-		return nil, fmt.Errorf("failed to query chaincode. No results found for iterator")
+		assets = append(assets, asset)
 	}
 
 	sort.SliceStable(assets, func(i, j int) bool {
@@ -141,110 +246,374 @@ func stringQuery(ctx contractapi.TransactionContextInterface, queryString string
 	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
 
 	if err != nil {
-		return nil, err
+		return nil, errs.Internalf("failed to query world state: %v", err)
 	}
 	defer resultsIterator.Close()
 
 	return iteratorSlicer(resultsIterator)
 }
 
+func stringQueryPage(ctx contractapi.TransactionContextInterface, queryString string, pageSize int32, bookmark string) (internal.StoredStatPage, error) {
+	if err := mango.ValidatePageSize(pageSize); err != nil {
+		return internal.StoredStatPage{}, err
+	}
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(queryString, pageSize, bookmark)
+	if err != nil {
+		return internal.StoredStatPage{}, errs.Internalf("failed to query world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	assets, err := iteratorSlicer(resultsIterator)
+	if err != nil {
+		return internal.StoredStatPage{}, err
+	}
+	return internal.CreateStoredStatPage(assets, metadata.FetchedRecordsCount, metadata.Bookmark), nil
+}
+
+func hostnameQuery(hostname string) mango.Query {
+	return mango.NewQuery(mango.Eq("hostname", hostname))
+}
+
 func (s *SmartContract) GetAssetResource(ctx contractapi.TransactionContextInterface, hostname string) ([]internal.StoredStat, error) {
-	assetQuery := fmt.Sprintf(`{"selector": {"hostname": "%s"}}`, hostname)
-	return stringQuery(ctx, assetQuery)
+	return stringQuery(ctx, hostnameQuery(hostname).String())
+}
+
+// GetAssetResourcePage returns a page of GetAssetResource, an empty bookmark starts from the first stat
+func (s *SmartContract) GetAssetResourcePage(ctx contractapi.TransactionContextInterface, hostname string, pageSize int32, bookmark string) (internal.StoredStatPage, error) {
+	return stringQueryPage(ctx, hostnameQuery(hostname).String(), pageSize, bookmark)
 }
 
+// GetAssetResourceListTime returns the stats of the host during the last minutes, "now" being the transaction timestamp
 func (s *SmartContract) GetAssetResourceListTime(ctx contractapi.TransactionContextInterface, hostname string, minutes int) ([]internal.StoredStat, error) {
-	timeStart := time.Now()
-	timeEnd := timeStart.Add(time.Duration(-time.Duration(minutes) * time.Minute))
-	assetQuery := fmt.Sprintf(`{"selector": {"hostname": "%s","timestamp.timeSeconds": {"$lt": %d,"$gte": %d}}}`, hostname, timeStart.Unix(), timeEnd.Unix())
-	return stringQuery(ctx, assetQuery)
+	fromSeconds, toSeconds, err := clock.Window(clock.New(ctx.GetStub()), minutes)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAssetResourceListRange(ctx, hostname, fromSeconds, toSeconds)
 }
 
-func (s *SmartContract) GetLastResourceSummary(ctx contractapi.TransactionContextInterface, hostname string) (internal.StatSummary, error) {
-	var statSummary internal.StatSummary
-	assetQuery := fmt.Sprintf(`{"selector": {"hostname": "%s"}, "sort": [{"timestamp.timeSeconds": "desc"}], "limit": 2,"use_index": "resource_index"}`, hostname)
-	queryResult, err := stringQuery(ctx, assetQuery)
+// GetAssetResourceListRange returns the stats of the host with fromSeconds <= timestamp < toSeconds (unix seconds)
+func (s *SmartContract) GetAssetResourceListRange(ctx contractapi.TransactionContextInterface, hostname string, fromSeconds int64, toSeconds int64) ([]internal.StoredStat, error) {
+	return statsBetween(ctx, hostname, fromSeconds, toSeconds)
+}
+
+// GetAssetResourceListTimePage returns a page of GetAssetResourceListTime
+func (s *SmartContract) GetAssetResourceListTimePage(ctx contractapi.TransactionContextInterface, hostname string, minutes int, pageSize int32, bookmark string) (internal.StoredStatPage, error) {
+	fromSeconds, toSeconds, err := clock.Window(clock.New(ctx.GetStub()), minutes)
 	if err != nil {
-		return statSummary, err
+		return internal.StoredStatPage{}, err
+	}
+	return s.GetAssetResourceListRangePage(ctx, hostname, fromSeconds, toSeconds, pageSize, bookmark)
+}
+
+// GetAssetResourceListRangePage returns a page of GetAssetResourceListRange in key order, oldest first, the updatable stats
+// coming with the last page. The bookmark is the key the next page starts from
+func (s *SmartContract) GetAssetResourceListRangePage(ctx contractapi.TransactionContextInterface, hostname string, fromSeconds int64, toSeconds int64, pageSize int32, bookmark string) (internal.StoredStatPage, error) {
+	samples, next, err := series.BetweenPage(ctx.GetStub(), internal.StatObjectType, hostname, fromSeconds, toSeconds, pageSize, bookmark)
+	if err != nil {
+		return internal.StoredStatPage{}, err
+	}
+	stats, err := samplesToStats(samples)
+	if err != nil {
+		return internal.StoredStatPage{}, err
+	}
+	if next == "" {
+		updatable, err := updatableStatBetween(ctx, hostname, fromSeconds, toSeconds)
+		if err != nil {
+			return internal.StoredStatPage{}, err
+		}
+		stats = append(stats, updatable...)
 	}
+	return internal.CreateStoredStatPage(stats, int32(len(stats)), next), nil
+}
 
-	statSummary = internal.SummarizeStoredStat(queryResult[0])
+// statsBetween returns the stats of the host with fromSeconds <= timestamp < toSeconds, newest first:
+// its time series (unique mode) and its updatable stats. Composite and single key reads work on LevelDB and are re-validated at commit
+func statsBetween(ctx contractapi.TransactionContextInterface, hostname string, fromSeconds int64, toSeconds int64) ([]internal.StoredStat, error) {
+	samples, err := series.Between(ctx.GetStub(), internal.StatObjectType, hostname, fromSeconds, toSeconds)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := samplesToStats(samples)
+	if err != nil {
+		return nil, err
+	}
+	updatable, err := updatableStatBetween(ctx, hostname, fromSeconds, toSeconds)
+	if err != nil {
+		return nil, err
+	}
+	stats = append(stats, updatable...)
 
-	return statSummary, nil
+	sort.SliceStable(stats, func(i, j int) bool {
+		return series.TimeAttribute(stats[i].Timestamp) > series.TimeAttribute(stats[j].Timestamp)
+	})
+	return stats, nil
 }
 
-func (s *SmartContract) GetSummaryAnalysisTime(ctx contractapi.TransactionContextInterface, hostname string, minutes int) (internal.StatAnalysis, error) {
-	var statAnalysis internal.StatAnalysis
-	storedStatList, err := s.GetAssetResourceListTime(ctx, hostname, minutes)
+// updatableStatBetween returns the updatable stats of the host when fromSeconds <= timestamp < toSeconds
+func updatableStatBetween(ctx contractapi.TransactionContextInterface, hostname string, fromSeconds int64, toSeconds int64) ([]internal.StoredStat, error) {
+	updatable, err := updatableStat(ctx, hostname)
+	if err != nil || updatable == nil {
+		return []internal.StoredStat{}, err
+	}
+	at := series.TimeAttribute(updatable.Timestamp)
+	if at < series.TimeAttribute(model.Timestamp{TimeSeconds: fromSeconds}) || at >= series.TimeAttribute(model.Timestamp{TimeSeconds: toSeconds}) {
+		return []internal.StoredStat{}, nil
+	}
+	return []internal.StoredStat{*updatable}, nil
+}
+
+// updatableStat returns the stats stored under hostname (updatable mode), nil when there are none
+func updatableStat(ctx contractapi.TransactionContextInterface, hostname string) (*internal.StoredStat, error) {
+	if hostname == "" {
+		return nil, nil
+	}
+	statJSON, err := ctx.GetStub().GetState(hostname)
+	if err != nil {
+		return nil, errs.Internalf("failed to read from world state: %v", err)
+	}
+	if statJSON == nil {
+		return nil, nil
+	}
+	stat, err := internal.JsonToStoredStat(string(statJSON))
+	if err != nil {
+		return nil, errs.Internalf("failed to decode the Stats for %s. %v", hostname, err)
+	}
+	// KEYS CHOSEN BY OLDER CLIENTS CAN HOLD THE STATS OF ANOTHER HOST
+	if stat.Hostname != hostname {
+		return nil, nil
+	}
+	return &stat, nil
+}
+
+// latestStoredStat returns the newest of the latest stats in the time series of the host and its updatable stats, nil when there are none
+func latestStoredStat(ctx contractapi.TransactionContextInterface, hostname string) (*internal.StoredStat, error) {
+	if hostname == "" {
+		return nil, nil
+	}
+	samples, err := series.Last(ctx.GetStub(), internal.StatObjectType, hostname, 1)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := samplesToStats(samples)
+	if err != nil {
+		return nil, err
+	}
+	updatable, err := updatableStat(ctx, hostname)
+	if err != nil {
+		return nil, err
+	}
+	if updatable != nil {
+		stats = append(stats, *updatable)
+	}
+
+	var latest *internal.StoredStat
+	for i := range stats {
+		if latest == nil || series.TimeAttribute(stats[i].Timestamp) > series.TimeAttribute(latest.Timestamp) {
+			latest = &stats[i]
+		}
+	}
+	return latest, nil
+}
+
+// GetLastResourceSummary summarizes the latest stats of the host, from its time series, its updatable stats or its offloaded stats
+func (s *SmartContract) GetLastResourceSummary(ctx contractapi.TransactionContextInterface, hostname string) (model.StatSummary, error) {
+	latest, err := latestStoredStat(ctx, hostname)
+	if err != nil {
+		return model.StatSummary{}, err
+	}
+	summaries := []model.StatSummary{}
+	if latest != nil {
+		summaries = append(summaries, internal.SummarizeStoredStat(*latest))
+	}
+	if hostname != "" {
+		offloadedStats, err := s.GetLastOffloadedStats(ctx, hostname, 1)
+		if err != nil {
+			return model.StatSummary{}, err
+		}
+		for _, offloadedStat := range offloadedStats {
+			summaries = append(summaries, offloadedStat.Summary)
+		}
+	}
+	if len(summaries) == 0 {
+		return model.StatSummary{}, errs.NotFoundf("no Stats were found for %s", hostname)
+	}
+	sortSummaries(summaries)
+	return summaries[0], nil
+}
+
+// GetSummaryAnalysisTime summarizes the stats of the host during the last minutes, "now" being the transaction timestamp
+func (s *SmartContract) GetSummaryAnalysisTime(ctx contractapi.TransactionContextInterface, hostname string, minutes int) (model.StatAnalysis, error) {
+	fromSeconds, toSeconds, err := clock.Window(clock.New(ctx.GetStub()), minutes)
+	if err != nil {
+		return model.StatAnalysis{}, err
+	}
+	return s.GetSummaryAnalysisRange(ctx, hostname, fromSeconds, toSeconds)
+}
+
+// GetSummaryAnalysisRange summarizes the stats of the host, offloaded stats included, with fromSeconds <= timestamp < toSeconds (unix seconds)
+func (s *SmartContract) GetSummaryAnalysisRange(ctx contractapi.TransactionContextInterface, hostname string, fromSeconds int64, toSeconds int64) (model.StatAnalysis, error) {
+	var statAnalysis model.StatAnalysis
+	storedStatList, err := s.GetAssetResourceListRange(ctx, hostname, fromSeconds, toSeconds)
 	if err != nil {
 		return statAnalysis, err
 	}
-	var statSummarySlice []internal.StatSummary
+	statSummarySlice := []model.StatSummary{}
 	for _, stat := range storedStatList {
 		var statSummary = internal.SummarizeStoredStat(stat)
 		statSummarySlice = append(statSummarySlice, statSummary)
 	}
+	// OFFLOADED STATS ONLY KEEP THEIR SUMMARY IN THE LEDGER
+	offloadedSummaries, err := offloadedSummariesBetween(ctx, hostname, fromSeconds, toSeconds)
+	if err != nil {
+		return statAnalysis, err
+	}
+	statSummarySlice = append(statSummarySlice, offloadedSummaries...)
+	sortSummaries(statSummarySlice)
 
 	statAnalysis.Hostname = hostname
-	statAnalysis.Duration = minutes
+	statAnalysis.Duration = clock.WindowMinutes(fromSeconds, toSeconds)
 	statAnalysis.StatSummary = statSummarySlice
 	statAnalysis = internal.AnalizeStatSummary(statAnalysis)
 
 	return statAnalysis, nil
 }
 
+// sortSummaries sorts the summaries newest first
+func sortSummaries(summaries []model.StatSummary) {
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return series.TimeAttribute(summaries[i].Timestamp) > series.TimeAttribute(summaries[j].Timestamp)
+	})
+}
+
 // GetAllAssets returns all assets found in world state
 func (s *SmartContract) GetAllAssets(ctx contractapi.TransactionContextInterface) ([]*internal.StoredStat, error) {
 	// range query with empty string for startKey and endKey does an
 	// open-ended query of all assets in the chaincode namespace.
 	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
 	if err != nil {
-		return nil, err
+		return nil, errs.Internalf("failed to read from world state: %v", err)
 	}
 	defer resultsIterator.Close()
 
-	var statObjects []*internal.StoredStat
+	statObjects, err := readStatPointers(resultsIterator, []*internal.StoredStat{})
+	if err != nil {
+		return nil, err
+	}
+
+	// RANGE QUERIES SKIP COMPOSITE KEYS, THE TIME SERIES ARE READ SEPARATELY
+	seriesIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(internal.StatObjectType, []string{})
+	if err != nil {
+		return nil, errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer seriesIterator.Close()
+	statObjects, err = readStatPointers(seriesIterator, statObjects)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(statObjects, func(i, j int) bool {
+		return statObjects[i].Timestamp.TimeSeconds > statObjects[j].Timestamp.TimeSeconds
+	})
+
+	return statObjects, nil
+}
+
+func readStatPointers(resultsIterator shim.StateQueryIteratorInterface, statObjects []*internal.StoredStat) ([]*internal.StoredStat, error) {
 	for resultsIterator.HasNext() {
 		queryResponse, err := resultsIterator.Next()
 		if err != nil {
-			return nil, err
+			return nil, errs.Internalf("failed to read query results: %v", err)
 		}
 
 		var statObject internal.StoredStat
 		err = json.Unmarshal(queryResponse.Value, &statObject)
 		if err != nil {
-			return nil, err
+			return nil, errs.Internalf("failed to decode the Stats for %s. %v", queryResponse.Key, err)
 		}
 		statObjects = append(statObjects, &statObject)
 	}
+	return statObjects, nil
+}
 
-	sort.SliceStable(statObjects, func(i, j int) bool {
-		return statObjects[i].Timestamp.TimeSeconds > statObjects[j].Timestamp.TimeSeconds
-	})
+// GetResourceSamplesPage returns a page of the time series of the host (unique mode), oldest first, or of every host when hostname is empty.
+// An empty bookmark starts from the first stat
+func (s *SmartContract) GetResourceSamplesPage(ctx contractapi.TransactionContextInterface, hostname string, pageSize int32, bookmark string) (internal.StoredStatPage, error) {
+	samples, fetchedCount, next, err := series.Page(ctx.GetStub(), internal.StatObjectType, hostname, pageSize, bookmark)
+	if err != nil {
+		return internal.StoredStatPage{}, err
+	}
+	stats, err := samplesToStats(samples)
+	if err != nil {
+		return internal.StoredStatPage{}, err
+	}
+	return internal.CreateStoredStatPage(stats, fetchedCount, next), nil
+}
 
-	return statObjects, nil
+// GetAllAssetsPage returns a page of the stats stored under a single key (updatable mode), an empty bookmark starts from the first stat.
+// Range queries skip composite keys, the time series are paged with GetResourceSamplesPage
+func (s *SmartContract) GetAllAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (internal.StoredStatPage, error) {
+	if err := mango.ValidatePageSize(pageSize); err != nil {
+		return internal.StoredStatPage{}, err
+	}
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", pageSize, bookmark)
+	if err != nil {
+		return internal.StoredStatPage{}, errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	assets, err := iteratorSlicer(resultsIterator)
+	if err != nil {
+		return internal.StoredStatPage{}, err
+	}
+	return internal.CreateStoredStatPage(assets, metadata.FetchedRecordsCount, metadata.Bookmark), nil
 }
 
 // Function for testing CouchDB Queries
 func (s *SmartContract) ExecuteQuery(ctx contractapi.TransactionContextInterface, assetQuery string) ([]string, error) {
-	var result []string
+	result := []string{}
 	resultsIterator, err := ctx.GetStub().GetQueryResult(assetQuery)
 	if err != nil {
-		return result, err
+		return nil, errs.InvalidArgumentf("failed to execute query: %v", err)
 	}
 	defer resultsIterator.Close()
-	if resultsIterator.HasNext() {
-		for resultsIterator.HasNext() {
-			queryResponse, err := resultsIterator.Next()
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, string(queryResponse.Value))
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, errs.Internalf("failed to read query results: %v", err)
 		}
-	} else {
-		return nil, fmt.Errorf("failed to query chaincode. No results found for iterator")
+		result = append(result, string(queryResponse.Value))
 	}
 
 	return result, nil
 
 }
+
+// CONFIGURATION OF THE INVOKED SMART CONTRACTS
+// SetConfig stores the names of the invoked Smart Contracts and their channel, only for admin clients
+func (s *SmartContract) SetConfig(ctx contractapi.TransactionContextInterface, configJson string) (invoke.Config, error) {
+	err := access.AssertAdmin(ctx.GetClientIdentity())
+	if err != nil {
+		return invoke.Config{}, err
+	}
+	config, err := invoke.JsonToConfig(configJson)
+	if err != nil {
+		return invoke.Config{}, err
+	}
+	return invoke.PutConfig(ctx.GetStub(), config)
+}
+
+// GetConfig returns the names of the invoked Smart Contracts and their channel
+func (s *SmartContract) GetConfig(ctx contractapi.TransactionContextInterface) (invoke.Config, error) {
+	return invoke.GetConfig(ctx.GetStub())
+}
+
+// initConfig stores the default configuration unless one was already set
+func initConfig(ctx contractapi.TransactionContextInterface) error {
+	config, err := invoke.GetConfig(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+	_, err = invoke.PutConfig(ctx.GetStub(), config)
+	return err
+}
@@ -0,0 +1,70 @@
+package chaincode
+
+import (
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/clock"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/invoke"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/series"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/validation"
+	"github.com/dmonteroh/distributed-resources-smartcontract/resources-sc/internal"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ValidateStats returns the field-level violations of the DrcStats as if they were ingested now, without storing them
+func (s *SmartContract) ValidateStats(ctx contractapi.TransactionContextInterface, statsJson string) (validation.Report, error) {
+	drcStats, err := internal.DrcJsonToStruct(statsJson)
+	if err != nil {
+		return validation.Report{}, errs.InvalidArgumentf("invalid stats: %v", err)
+	}
+	return validateStats(ctx, drcStats)
+}
+
+// validateStats checks the values of the stats, their timestamp against the transaction timestamp,
+// their boot time against the latest stats of the host, stored or offloaded, and that the host is registered in the inventory
+func validateStats(ctx contractapi.TransactionContextInterface, drcStats internal.DrcStats) (validation.Report, error) {
+	now, err := clock.New(ctx.GetStub()).Now()
+	if err != nil {
+		return validation.Report{}, err
+	}
+	previous, err := previousStat(ctx, drcStats.DrcHost.Hostname)
+	if err != nil {
+		return validation.Report{}, err
+	}
+
+	report := validation.NewReport()
+	internal.ValidateDrcStats(&report, drcStats, previous, now)
+
+	config, err := invoke.GetConfig(ctx.GetStub())
+	if err != nil {
+		return validation.Report{}, err
+	}
+	err = report.Registered(ctx.GetStub(), config.InventoryChaincode(), "host.hostname", drcStats.DrcHost.Hostname)
+	if err != nil {
+		return validation.Report{}, err
+	}
+	return report, nil
+}
+
+// previousStat returns the latest stats of the host across its stored and offloaded stats, nil when there are none.
+// Offloaded stats only carry their timestamp and boot time
+func previousStat(ctx contractapi.TransactionContextInterface, hostname string) (*internal.StoredStat, error) {
+	previous, err := latestStoredStat(ctx, hostname)
+	if err != nil || hostname == "" {
+		return previous, err
+	}
+	samples, err := series.Last(ctx.GetStub(), internal.OffloadedStatObjectType, hostname, 1)
+	if err != nil {
+		return nil, err
+	}
+	offloadedStats, err := samplesToOffloadedStats(samples)
+	if err != nil {
+		return nil, err
+	}
+	for _, offloadedStat := range offloadedStats {
+		if previous == nil || series.TimeAttribute(offloadedStat.Timestamp) > series.TimeAttribute(previous.Timestamp) {
+			stat := offloadedStat.PreviousStat()
+			previous = &stat
+		}
+	}
+	return previous, nil
+}
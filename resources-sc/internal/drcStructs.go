@@ -2,8 +2,10 @@ package internal
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
 	"github.com/wI2L/jettison"
 )
 
@@ -76,18 +78,6 @@ func (d DrcDockerStats) String() string {
 	return string(s)
 }
 
-// -- TIMESTAMP
-type DrcTimestamp struct {
-	TimeLocal   time.Time `json:"timeLocal"`
-	TimeSeconds int64     `json:"timeSeconds"`
-	TimeNano    int64     `json:"timeNano"`
-}
-
-func (d DrcTimestamp) String() string {
-	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
-	return string(s)
-}
-
 // -- HOST INFO
 type DrcHost struct {
 	Hostname             string `json:"hostname"`
@@ -106,7 +96,7 @@ func (d DrcHost) String() string {
 
 // -- RESPONSE OBJECT
 type DrcStats struct {
-	Timestamp  DrcTimestamp     `json:"timestamp"`
+	Timestamp  model.Timestamp  `json:"timestamp"`
 	DrcHost    DrcHost          `json:"host"`
 	CPUStats   DrcCPUStats      `json:"cpuStats"`
 	MemStats   DrcMemStats      `json:"memStats"`
@@ -116,15 +106,16 @@ type DrcStats struct {
 }
 
 type StoredStat struct {
-	ID         string           `json:"id"`
-	Hostname   string           `json:"hostname"`
-	Timestamp  DrcTimestamp     `json:"timestamp"`
-	DrcHost    DrcHost          `json:"host"`
-	CPUStats   DrcCPUStats      `json:"cpuStats"`
-	MemStats   DrcMemStats      `json:"memStats"`
-	DiskStats  []DrcDiskStats   `json:"diskStats"`
-	ProcStats  DrcProcStats     `json:"procStats"`
-	DockerSats []DrcDockerStats `json:"dockerStats"`
+	ID          string           `json:"id"`
+	Hostname    string           `json:"hostname"`    //set from host.hostname
+	SubmittedBy string           `json:"submittedBy"` //MSP ID of the submitting client
+	Timestamp   model.Timestamp  `json:"timestamp"`
+	DrcHost     DrcHost          `json:"host"`
+	CPUStats    DrcCPUStats      `json:"cpuStats"`
+	MemStats    DrcMemStats      `json:"memStats"`
+	DiskStats   []DrcDiskStats   `json:"diskStats"`
+	ProcStats   DrcProcStats     `json:"procStats"`
+	DockerSats  []DrcDockerStats `json:"dockerStats"`
 }
 
 func JsonToStoredStat(v string) (storedStat StoredStat, err error) {
@@ -147,10 +138,10 @@ func DrcJsonToStruct(v string) (drcStats DrcStats, err error) {
 	return drcStats, err
 }
 
-func ConvertToStorage(drcStats DrcStats) StoredStat {
+func ConvertToStorage(id string, drcStats DrcStats) StoredStat {
 	return StoredStat{
-		ID:         "",
-		Hostname:   "",
+		ID:         id,
+		Hostname:   drcStats.DrcHost.Hostname,
 		Timestamp:  drcStats.Timestamp,
 		DrcHost:    drcStats.DrcHost,
 		CPUStats:   drcStats.CPUStats,
@@ -166,18 +157,118 @@ func (d StoredStat) String() string {
 	return string(s)
 }
 
-////
+// -- STORAGE CONFIGURATIONS
+// unique keeps every stat of a host in its time series (common/series) under the composite key (resourceStat, hostname, time),
+// updatable keeps only the latest one under hostname and offloaded keeps the full stat in a content-addressed store (common/offload),
+// the ledger only keeps an OffloadedStat in the time series (offloadedStat, hostname, time)
+const (
+	StorageUnique    = "unique"
+	StorageUpdatable = "updatable"
+	StorageOffloaded = "offloaded"
+
+	StatObjectType          = "resourceStat"
+	OffloadedStatObjectType = "offloadedStat"
+
+	// Key of the transient map carrying the full DrcStats of an offloaded stat, transaction arguments are stored in the blocks
+	StatsTransientKey = "stats"
+
+	dateLayoutID = "2006-01-02T15:04:05.000000000"
+)
+
+var StorageModes = []string{StorageUnique, StorageUpdatable, StorageOffloaded}
+
+// CreateStatID derives the ID of the stat, the key of updatable stats. Unique and offloaded stats are stored under their time series key
+func CreateStatID(mode string, hostname string, timestamp model.Timestamp) (string, error) {
+	if mode == StorageUnique || mode == StorageOffloaded {
+		return hostname + "-" + DateFormatID(timestamp), nil
+	} else if mode == StorageUpdatable {
+		return hostname, nil
+	} else {
+		return "", fmt.Errorf("unsupported storage mode %q, expected any of %v", mode, StorageModes)
+	}
+}
+
+// ParseStatID returns the hostname and timestamp of the ID of a unique or offloaded stat
+func ParseStatID(id string) (string, model.Timestamp, error) {
+	if len(id) < len(dateLayoutID)+2 || id[len(id)-len(dateLayoutID)-1] != '-' {
+		return "", model.Timestamp{}, fmt.Errorf("invalid stat ID %q, expected hostname-%s", id, dateLayoutID)
+	}
+	t, err := time.Parse(dateLayoutID, id[len(id)-len(dateLayoutID):])
+	if err != nil {
+		return "", model.Timestamp{}, fmt.Errorf("invalid stat ID %q: %v", id, err)
+	}
+	return id[:len(id)-len(dateLayoutID)-1], model.CreateTimestamp(t), nil
+}
+
+// DateFormatID formats the timestamp in UTC with nanoseconds, every peer derives the same key
+func DateFormatID(timestamp model.Timestamp) string {
+	t := time.Unix(timestamp.TimeSeconds, 0)
+	if timestamp.TimeNano != 0 {
+		t = time.Unix(0, timestamp.TimeNano)
+	}
+	return t.UTC().Format(dateLayoutID)
+}
 
-type StatSummary struct {
-	ID                  string       `json:"id"`
-	Timestamp           DrcTimestamp `json:"timestamp"`
-	CPUAverageUsage     float64      `json:"cpuAverageUsage"`
-	MemoryUsePercentage float64      `json:"MemoryUsePercentage"`
-	ContainersRunning   int          `json:"containersRunning"`
+// -- OFFLOADED STATS
+// Ledger record of a stat kept off the ledger: its summary and the hash, size and URI of the full DrcStats
+type OffloadedStat struct {
+	ID          string            `json:"id"`
+	Hostname    string            `json:"hostname"`
+	SubmittedBy string            `json:"submittedBy"` //MSP ID of the submitting client
+	Timestamp   model.Timestamp   `json:"timestamp"`
+	BootTime    int64             `json:"bootTime"` //host.boottime of the offloaded DrcStats, checked against the next stats of the host
+	Summary     model.StatSummary `json:"summary"`
+	ContentHash string            `json:"contentHash"` //hex SHA-256 of the offloaded bytes
+	Size        int64             `json:"size"`        //bytes
+	URI         string            `json:"uri"`
 }
 
-func SummarizeStoredStat(d StoredStat) StatSummary {
-	var summary StatSummary
+func (d OffloadedStat) String() string {
+	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
+	return string(s)
+}
+
+func JsonToOffloadedStat(v string) (offloadedStat OffloadedStat, err error) {
+	err = json.Unmarshal([]byte(v), &offloadedStat)
+	return offloadedStat, err
+}
+
+// PreviousStat returns the part of the offloaded stat the next stats of the host are validated against
+func (d OffloadedStat) PreviousStat() StoredStat {
+	return StoredStat{ID: d.ID, Hostname: d.Hostname, Timestamp: d.Timestamp, DrcHost: DrcHost{Hostname: d.Hostname, BootTime: d.BootTime}}
+}
+
+// Result of VerifyOffloadedStat, a payload that does not match is not an error
+type OffloadVerification struct {
+	ID           string `json:"id"`
+	Valid        bool   `json:"valid"`
+	Reason       string `json:"reason"` //why the payload does not match, "" when valid
+	ContentHash  string `json:"contentHash"`
+	ComputedHash string `json:"computedHash"`
+	Size         int64  `json:"size"`
+	ComputedSize int64  `json:"computedSize"`
+	URI          string `json:"uri"`
+}
+
+// -- PAGINATION
+// Page of stored stats, the bookmark is sent back to fetch the next page
+type StoredStatPage struct {
+	Records      []StoredStat `json:"records"`
+	FetchedCount int32        `json:"fetchedCount"`
+	Bookmark     string       `json:"bookmark"`
+}
+
+func CreateStoredStatPage(records []StoredStat, fetchedCount int32, bookmark string) StoredStatPage {
+	if records == nil {
+		records = []StoredStat{}
+	}
+	return StoredStatPage{Records: records, FetchedCount: fetchedCount, Bookmark: bookmark}
+}
+
+////
+
+func SummarizeStoredStat(d StoredStat) model.StatSummary {
+	var summary model.StatSummary
 	summary.ID = d.ID
 	summary.Timestamp = d.Timestamp
 	summary.CPUAverageUsage = d.CPUStats.AverageUsage
@@ -195,21 +286,7 @@ func SummarizeStoredStat(d StoredStat) StatSummary {
 	return summary
 }
 
-func (d StatSummary) String() string {
-	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
-	return string(s)
-}
-
-type StatAnalysis struct {
-	Hostname            string        `json:"hostname"`
-	Duration            int           `json:"duration"`
-	CPUAverageUsage     float64       `json:"cpuAverageUsage"`
-	MemoryUsePercentage float64       `json:"MemoryUsePercentage"`
-	ContainersRunning   int           `json:"containersRunning"`
-	StatSummary         []StatSummary `json:"statSummary"`
-}
-
-func AnalizeStatSummary(statAnalysis StatAnalysis) StatAnalysis {
+func AnalizeStatSummary(statAnalysis model.StatAnalysis) model.StatAnalysis {
 	if len(statAnalysis.StatSummary) > 0 {
 		var CPUAverageUsage float64 = 0
 		var MemoryUsePercentage float64 = 0
@@ -226,8 +303,3 @@ func AnalizeStatSummary(statAnalysis StatAnalysis) StatAnalysis {
 
 	return statAnalysis
 }
-
-func (d StatAnalysis) String() string {
-	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
-	return string(s)
-}
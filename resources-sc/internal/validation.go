@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"math"
+	"time"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/series"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/validation"
+)
+
+// ValidateDrcStats adds the violations of the stats to the report. When now is set the timestamp must be within
+// validation.MaxClockSkew of it, and when previous (the latest stats of the host) is set the boot time can not go back
+func ValidateDrcStats(report *validation.Report, stats DrcStats, previous *StoredStat, now time.Time) {
+	report.Required("host.hostname", stats.DrcHost.Hostname)
+	report.Timestamp("timestamp", stats.Timestamp, now)
+
+	report.Range("cpuStats.averageUsage", stats.CPUStats.AverageUsage, 0, 100)
+	for i, usage := range stats.CPUStats.CoreUsage {
+		report.Range(validation.Index("cpuStats.coreUsage", i, ""), usage, 0, 100)
+	}
+
+	report.Range("memStats.used", stats.MemStats.Used, 0, 100)
+	report.Max("memStats.available", float64(stats.MemStats.Available), "memStats.total", float64(stats.MemStats.Total))
+
+	for i, disk := range stats.DiskStats {
+		report.Max(validation.Index("diskStats", i, "used"), float64(disk.Used), validation.Index("diskStats", i, "total"), float64(disk.Total))
+		report.Range(validation.Index("diskStats", i, "usedPercent"), disk.UsedPercent, 0, 100)
+	}
+
+	report.Range("procStats.totalProcs", float64(stats.ProcStats.TotalProcs), 0, math.Inf(1))
+	report.Range("procStats.createdProcs", float64(stats.ProcStats.CreatedProcs), 0, math.Inf(1))
+	report.Range("procStats.runningProcs", float64(stats.ProcStats.RunningProcs), 0, math.Inf(1))
+	report.Range("procStats.blockedProcs", float64(stats.ProcStats.BlockedProcs), 0, math.Inf(1))
+
+	// BOOT TIME 0 MEANS THE COLLECTOR DID NOT REPORT IT
+	bootTime := stats.DrcHost.BootTime
+	if bootTime == 0 {
+		return
+	}
+	if stats.Timestamp.TimeSeconds > 0 && bootTime > stats.Timestamp.TimeSeconds {
+		report.Add("host.boottime", validation.RuleRange, "the host booted at %d, after the stats were taken (%d)", bootTime, stats.Timestamp.TimeSeconds)
+	}
+	if previous != nil && series.TimeAttribute(previous.Timestamp) < series.TimeAttribute(stats.Timestamp) && bootTime < previous.DrcHost.BootTime {
+		report.Add("host.boottime", validation.RuleMonotonic, "the host booted at %d, before the boot time of its previous stats (%d)", bootTime, previous.DrcHost.BootTime)
+	}
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/validation"
+)
+
+var now = time.Unix(1700000000, 0)
+
+func validStats() DrcStats {
+	return DrcStats{
+		Timestamp: model.Timestamp{TimeLocal: now, TimeSeconds: now.Unix(), TimeNano: now.UnixNano()},
+		DrcHost:   DrcHost{Hostname: "host-1", BootTime: now.Unix() - 3600},
+		CPUStats:  DrcCPUStats{AverageUsage: 12.5, CoreUsage: []float64{10, 15}},
+		MemStats:  DrcMemStats{Total: 16, Available: 8, Used: 50},
+		DiskStats: []DrcDiskStats{{Total: 100, Used: 40, UsedPercent: 40}, {Total: 10, Used: 10, UsedPercent: 100}},
+		ProcStats: DrcProcStats{TotalProcs: 200, CreatedProcs: 5000, RunningProcs: 3},
+	}
+}
+
+func TestValidateDrcStats(t *testing.T) {
+	previous := &StoredStat{
+		Timestamp: model.Timestamp{TimeSeconds: now.Unix() - 60},
+		DrcHost:   DrcHost{Hostname: "host-1", BootTime: now.Unix() - 3600},
+	}
+	tests := []struct {
+		name     string
+		edit     func(s *DrcStats)
+		previous *StoredStat
+		field    string
+		rule     string
+	}{
+		{"valid", func(s *DrcStats) {}, previous, "", ""},
+		{"boot time not reported", func(s *DrcStats) { s.DrcHost.BootTime = 0 }, previous, "", ""},
+		{"no previous stats", func(s *DrcStats) { s.DrcHost.BootTime = 1 }, nil, "", ""},
+		{"older than the previous stats", func(s *DrcStats) {
+			s.Timestamp = model.Timestamp{TimeSeconds: now.Unix() - 120}
+			s.DrcHost.BootTime = 1
+		}, previous, "", ""},
+
+		{"hostname", func(s *DrcStats) { s.DrcHost.Hostname = "" }, previous, "host.hostname", validation.RuleRequired},
+		{"timestamp", func(s *DrcStats) { s.Timestamp = model.Timestamp{} }, previous, "timestamp", validation.RuleRequired},
+		{"clock skew", func(s *DrcStats) {
+			s.Timestamp = model.Timestamp{TimeSeconds: now.Add(validation.MaxClockSkew + time.Second).Unix()}
+		}, nil, "timestamp", validation.RuleSkew},
+		{"average usage", func(s *DrcStats) { s.CPUStats.AverageUsage = 100.1 }, previous, "cpuStats.averageUsage", validation.RuleRange},
+		{"core usage", func(s *DrcStats) { s.CPUStats.CoreUsage[1] = -1 }, previous, "cpuStats.coreUsage[1]", validation.RuleRange},
+		{"memory used", func(s *DrcStats) { s.MemStats.Used = 101 }, previous, "memStats.used", validation.RuleRange},
+		{"memory available", func(s *DrcStats) { s.MemStats.Available = 17 }, previous, "memStats.available", validation.RuleMax},
+		{"disk used", func(s *DrcStats) { s.DiskStats[1].Used = 11 }, previous, "diskStats[1].used", validation.RuleMax},
+		{"disk used percent", func(s *DrcStats) { s.DiskStats[0].UsedPercent = 140 }, previous, "diskStats[0].usedPercent", validation.RuleRange},
+		{"total procs", func(s *DrcStats) { s.ProcStats.TotalProcs = -1 }, previous, "procStats.totalProcs", validation.RuleRange},
+		{"created procs", func(s *DrcStats) { s.ProcStats.CreatedProcs = -1 }, previous, "procStats.createdProcs", validation.RuleRange},
+		{"running procs", func(s *DrcStats) { s.ProcStats.RunningProcs = -1 }, previous, "procStats.runningProcs", validation.RuleRange},
+		{"blocked procs", func(s *DrcStats) { s.ProcStats.BlockedProcs = -1 }, previous, "procStats.blockedProcs", validation.RuleRange},
+		{"booted after the stats", func(s *DrcStats) { s.DrcHost.BootTime = now.Unix() + 1 }, nil, "host.boottime", validation.RuleRange},
+		{"boot time went back", func(s *DrcStats) { s.DrcHost.BootTime = now.Unix() - 7200 }, previous, "host.boottime", validation.RuleMonotonic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := validStats()
+			tt.edit(&stats)
+			report := validation.NewReport()
+			ValidateDrcStats(&report, stats, tt.previous, now)
+			if tt.field == "" {
+				if !report.Valid {
+					t.Errorf("violations = %v, want none", report.Violations)
+				}
+				return
+			}
+			if report.Valid || len(report.Violations) != 1 {
+				t.Fatalf("violations = %v, want one on %s", report.Violations, tt.field)
+			}
+			violation := report.Violations[0]
+			if violation.Field != tt.field || violation.Rule != tt.rule {
+				t.Errorf("violation = %s %s, want %s %s", violation.Field, violation.Rule, tt.field, tt.rule)
+			}
+			if violation.Message == "" {
+				t.Errorf("violation of %s has no message", violation.Field)
+			}
+		})
+	}
+}
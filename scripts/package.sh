@@ -0,0 +1,22 @@
+#!/usr/bin/env bash
+# Vendors the dependencies of the chaincodes before they are packaged.
+# The chaincodes reference common through a replace directive (../common), which is not part of a chaincode package,
+# so go mod vendor copies it with the other dependencies into the vendor directory of each chaincode.
+# Usage: scripts/package.sh [chaincode...], every chaincode by default
+set -euo pipefail
+
+ROOT="$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)"
+CHAINCODES=("$@")
+if [ ${#CHAINCODES[@]} -eq 0 ]; then
+	CHAINCODES=(inventory-sc latency-sc resources-sc selector-sc)
+fi
+
+for CHAINCODE in "${CHAINCODES[@]}"; do
+	if [ ! -f "$ROOT/$CHAINCODE/go.mod" ]; then
+		echo "unknown chaincode $CHAINCODE" >&2
+		exit 1
+	fi
+	echo "vendoring $CHAINCODE"
+	# THE WORKSPACE (go.work) IS FOR LOCAL DEVELOPMENT, EACH CHAINCODE IS VENDORED FROM ITS OWN go.mod
+	(cd "$ROOT/$CHAINCODE" && GOWORK=off go mod vendor)
+done
@@ -0,0 +1,154 @@
+package chaincode
+
+import (
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/access"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/selector-sc/internal"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CreatePolicy stores the first version of a selection policy
+func (s *SmartContract) CreatePolicy(ctx contractapi.TransactionContextInterface, policyJson string) (internal.Policy, error) {
+	policy, err := internal.JsonToPolicy(policyJson)
+	if err != nil {
+		return internal.Policy{}, errs.InvalidArgumentf("invalid policy: %v", err)
+	}
+	versions, err := s.ListPolicyVersions(ctx, policy.ID)
+	if err != nil {
+		return internal.Policy{}, err
+	}
+	if len(versions) > 0 {
+		// ORGANIZATIONS THAT CAN NOT UPDATE THE POLICY ARE REFUSED BEFORE BEING TOLD IT EXISTS
+		err = assertPolicyModifier(ctx, versions[0])
+		if err != nil {
+			return internal.Policy{}, err
+		}
+		return internal.Policy{}, errs.AlreadyExistsf("the Policy with key: %s already exists", policy.ID)
+	}
+
+	policy.Version = 1
+	return putPolicy(ctx, policy)
+}
+
+// UpdatePolicy stores a new version of an existing selection policy, previous versions are kept.
+// Only the organization that created the policy or admins can update it
+func (s *SmartContract) UpdatePolicy(ctx contractapi.TransactionContextInterface, policyJson string) (internal.Policy, error) {
+	policy, err := internal.JsonToPolicy(policyJson)
+	if err != nil {
+		return internal.Policy{}, errs.InvalidArgumentf("invalid policy: %v", err)
+	}
+	versions, err := s.ListPolicyVersions(ctx, policy.ID)
+	if err != nil {
+		return internal.Policy{}, err
+	}
+	if len(versions) == 0 {
+		return internal.Policy{}, errs.NotFoundf("the Policy with key: %s does not exist", policy.ID)
+	}
+	err = assertPolicyModifier(ctx, versions[0])
+	if err != nil {
+		return internal.Policy{}, err
+	}
+
+	policy.Version = versions[len(versions)-1].Version + 1
+	return putPolicy(ctx, policy)
+}
+
+// GetPolicy returns the latest version of the policy
+func (s *SmartContract) GetPolicy(ctx contractapi.TransactionContextInterface, policyID string) (internal.Policy, error) {
+	versions, err := s.ListPolicyVersions(ctx, policyID)
+	if err != nil {
+		return internal.Policy{}, err
+	}
+	if len(versions) == 0 {
+		return internal.Policy{}, errs.NotFoundf("the Policy with key: %s does not exist", policyID)
+	}
+	return versions[len(versions)-1], nil
+}
+
+// GetPolicyVersion returns the given version of the policy
+func (s *SmartContract) GetPolicyVersion(ctx contractapi.TransactionContextInterface, policyID string, version int) (internal.Policy, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(internal.PolicyObjectType, []string{policyID, internal.PolicyVersionKey(version)})
+	if err != nil {
+		return internal.Policy{}, errs.Wrap(errs.InvalidArgument, err)
+	}
+	policyJson, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return internal.Policy{}, errs.Internalf("failed to read from world state: %v", err)
+	}
+	if policyJson == nil {
+		return internal.Policy{}, errs.NotFoundf("the Policy with key: %s and version: %d does not exist", policyID, version)
+	}
+	policy, err := internal.JsonToPolicy(string(policyJson))
+	if err != nil {
+		return internal.Policy{}, errs.Internalf("failed to decode the Policy with key: %s. %v", policyID, err)
+	}
+	return policy, nil
+}
+
+// ListPolicyVersions returns every version of the policy, oldest first
+func (s *SmartContract) ListPolicyVersions(ctx contractapi.TransactionContextInterface, policyID string) ([]internal.Policy, error) {
+	if policyID == "" {
+		return nil, errs.InvalidArgumentf("policy was posted without ID, ignored")
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(internal.PolicyObjectType, []string{policyID})
+	if err != nil {
+		return nil, errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	policies := []internal.Policy{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, errs.Internalf("failed to read query results: %v", err)
+		}
+		policy, err := internal.JsonToPolicy(string(queryResponse.Value))
+		if err != nil {
+			return nil, errs.Internalf("failed to decode the Policy with key: %s. %v", policyID, err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// assertPolicyModifier returns an error unless the client belongs to the organization that created the policy (first version) or is an admin
+func assertPolicyModifier(ctx contractapi.TransactionContextInterface, first internal.Policy) error {
+	mspID, err := access.MSPID(ctx.GetClientIdentity())
+	if err != nil {
+		return err
+	}
+	if mspID == first.UpdatedBy {
+		return nil
+	}
+	admin, err := access.IsAdmin(ctx.GetClientIdentity())
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return errs.Unauthorizedf("the organization %s did not create (%s) the Policy with key: %s and the client does not have the attribute %s=true", mspID, first.UpdatedBy, first.ID, access.AdminAttribute)
+	}
+	return nil
+}
+
+func putPolicy(ctx contractapi.TransactionContextInterface, policy internal.Policy) (internal.Policy, error) {
+	err := internal.ValidatePolicy(policy)
+	if err != nil {
+		return internal.Policy{}, err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return internal.Policy{}, errs.Internalf("failed to read client identity: %v", err)
+	}
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return internal.Policy{}, err
+	}
+	policy.UpdatedBy = mspID
+	policy.Timestamp = timestamp
+
+	key, err := ctx.GetStub().CreateCompositeKey(internal.PolicyObjectType, []string{policy.ID, internal.PolicyVersionKey(policy.Version)})
+	if err != nil {
+		return internal.Policy{}, errs.Wrap(errs.InvalidArgument, err)
+	}
+	return policy, errs.Wrap(errs.Internal, ctx.GetStub().PutState(key, []byte(policy.String())))
+}
@@ -1,9 +1,15 @@
 package chaincode
 
 import (
-	"fmt"
 	"sort"
+	"strconv"
 
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/access"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/clock"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/invoke"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/mango"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
 	"github.com/dmonteroh/distributed-resources-smartcontract/selector-sc/internal"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
@@ -21,25 +27,25 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 	for _, stat := range stats {
 		err := ctx.GetStub().PutState(stat.ID, []byte(stat.String()))
 		if err != nil {
-			return fmt.Errorf("failed to put to world state. %v", err)
+			return errs.Internalf("failed to put to world state. %v", err)
 		}
 	}
-	return nil
+	return initConfig(ctx)
 }
 
 // ReadAsset returns the asset stored in the world state with given id.
 func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, assetKey string) (internal.StoredSelection, error) {
 	statJSON, err := ctx.GetStub().GetState(assetKey)
 	if err != nil {
-		return internal.StoredSelection{}, fmt.Errorf("failed to read from world state: %v", err)
+		return internal.StoredSelection{}, errs.Internalf("failed to read from world state: %v", err)
 	}
 	if statJSON == nil {
-		return internal.StoredSelection{}, fmt.Errorf("the Asset with key: %s does not exist", assetKey)
+		return internal.StoredSelection{}, errs.NotFoundf("the Asset with key: %s does not exist", assetKey)
 	}
 
 	asset, err := internal.JsonToStoredSelection(string(statJSON))
 	if err != nil {
-		return internal.StoredSelection{}, fmt.Errorf("failed to read from world state: %v", err)
+		return internal.StoredSelection{}, errs.Internalf("failed to decode the Asset with key: %s. %v", assetKey, err)
 	}
 
 	return asset, nil
@@ -47,7 +53,7 @@ func (s *SmartContract) ReadAsset(ctx contractapi.TransactionContextInterface, a
 
 // CreateAsset issues a new asset to the world state with given details.
 func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface, assetJson string) error {
-	asset, err := internal.JsonToStoredSelection(assetJson)
+	asset, err := jsonToValidSelection(assetJson)
 	if err != nil {
 		return err
 	}
@@ -56,18 +62,18 @@ func (s *SmartContract) CreateAsset(ctx contractapi.TransactionContextInterface,
 		return err
 	}
 	if exists {
-		return fmt.Errorf("the Asset with key: %s already exists", asset.ID)
+		return errs.AlreadyExistsf("the Asset with key: %s already exists", asset.ID)
 	}
 
 	// RUN VALIDATIONS
 	validJson := []byte(asset.String())
 
-	return ctx.GetStub().PutState(asset.ID, validJson)
+	return errs.Wrap(errs.Internal, ctx.GetStub().PutState(asset.ID, validJson))
 }
 
 // UpdateAsset updates an existing asset in the world state with provided parameters.
 func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface, assetJson string) error {
-	asset, err := internal.JsonToStoredSelection(assetJson)
+	asset, err := jsonToValidSelection(assetJson)
 	if err != nil {
 		return err
 	}
@@ -76,13 +82,13 @@ func (s *SmartContract) UpdateAsset(ctx contractapi.TransactionContextInterface,
 		return err
 	}
 	if !exists {
-		return fmt.Errorf("the Asset with key: %s does not exist", asset.ID)
+		return errs.NotFoundf("the Asset with key: %s does not exist", asset.ID)
 	}
 
 	// RUN VALIDATIONS
 	validJson := []byte(asset.String())
 
-	return ctx.GetStub().PutState(asset.ID, validJson)
+	return errs.Wrap(errs.Internal, ctx.GetStub().PutState(asset.ID, validJson))
 }
 
 // DeleteAsset deletes an given asset from the world state.
@@ -92,61 +98,323 @@ func (s *SmartContract) DeleteAsset(ctx contractapi.TransactionContextInterface,
 		return err
 	}
 	if !exists {
-		return fmt.Errorf("the Asset with key: %s does not exist", assetKey)
+		return errs.NotFoundf("the Asset with key: %s does not exist", assetKey)
 	}
 
-	return ctx.GetStub().DelState(assetKey)
+	// EXPLANATION OF THE SELECTION IS REMOVED AS WELL
+	explanationKey, err := ctx.GetStub().CreateCompositeKey(internal.ExplanationObjectType, []string{assetKey})
+	if err != nil {
+		return errs.Wrap(errs.InvalidArgument, err)
+	}
+	err = ctx.GetStub().DelState(explanationKey)
+	if err != nil {
+		return errs.Wrap(errs.Internal, err)
+	}
+
+	return errs.Wrap(errs.Internal, ctx.GetStub().DelState(assetKey))
 }
 
 // AssetExists returns true when asset with given ID exists in world state
 func (s *SmartContract) AssetExists(ctx contractapi.TransactionContextInterface, assetKey string) (bool, error) {
 	asset, err := ctx.GetStub().GetState(assetKey)
 	if err != nil {
-		return false, fmt.Errorf("failed to read from world state: %v", err)
+		return false, errs.Internalf("failed to read from world state: %v", err)
 	}
 
 	return asset != nil, nil
 }
 
+// jsonToValidSelection decodes a selection posted by the client
+func jsonToValidSelection(assetJson string) (internal.StoredSelection, error) {
+	asset, err := internal.JsonToStoredSelection(assetJson)
+	if err != nil {
+		return internal.StoredSelection{}, errs.InvalidArgumentf("invalid selection: %v", err)
+	}
+	if asset.ID == "" {
+		return internal.StoredSelection{}, errs.InvalidArgumentf("selection was posted without ID, ignored")
+	}
+	return asset, nil
+}
+
 // GetAllAssets returns all assets found in world state
 func (s *SmartContract) GetAllAssets(ctx contractapi.TransactionContextInterface) ([]internal.StoredSelection, error) {
 	// range query with empty string for startKey and endKey does an
 	// open-ended query of all assets in the chaincode namespace.
 	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
 	if err != nil {
-		return nil, err
+		return nil, errs.Internalf("failed to read from world state: %v", err)
 	}
 	defer resultsIterator.Close()
 	return iteratorSlicer(resultsIterator)
 }
 
+// GetAllAssetsPage returns a page of the assets found in world state, an empty bookmark starts from the first asset
+func (s *SmartContract) GetAllAssetsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (internal.StoredSelectionPage, error) {
+	if err := mango.ValidatePageSize(pageSize); err != nil {
+		return internal.StoredSelectionPage{}, err
+	}
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", pageSize, bookmark)
+	if err != nil {
+		return internal.StoredSelectionPage{}, errs.Internalf("failed to read from world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	assets, err := iteratorSlicer(resultsIterator)
+	if err != nil {
+		return internal.StoredSelectionPage{}, err
+	}
+	return internal.CreateStoredSelectionPage(assets, metadata.FetchedRecordsCount, metadata.Bookmark), nil
+}
+
+// EXPLANATIONS ALSO HAVE A TARGET FIELD, ONLY DOCUMENTS WITH AN ASSET ID ARE SELECTIONS
+func selectionTargetQuery(asset string) mango.Query {
+	return mango.NewQuery(mango.Eq("target", asset), mango.Exists("assetID", true))
+}
+
+func selectionServerQuery(asset string) mango.Query {
+	return mango.NewQuery(mango.Eq("assetID", asset))
+}
+
 func (s *SmartContract) GetAllSelectionTarget(ctx contractapi.TransactionContextInterface, asset string) ([]internal.StoredSelection, error) {
-	assetQuery := fmt.Sprintf(`{"selector": {"target": "%s"}}`, asset)
-	return stringQuery(ctx, assetQuery)
+	return stringQuery(ctx, selectionTargetQuery(asset).String())
 }
 
 func (s *SmartContract) GetAllSelectionServer(ctx contractapi.TransactionContextInterface, asset string) ([]internal.StoredSelection, error) {
-	assetQuery := fmt.Sprintf(`{"selector": {"assetID": "%s"}}`, asset)
-	return stringQuery(ctx, assetQuery)
+	return stringQuery(ctx, selectionServerQuery(asset).String())
+}
+
+func (s *SmartContract) GetAllSelectionTargetPage(ctx contractapi.TransactionContextInterface, asset string, pageSize int32, bookmark string) (internal.StoredSelectionPage, error) {
+	return stringQueryPage(ctx, selectionTargetQuery(asset).String(), pageSize, bookmark)
+}
+
+func (s *SmartContract) GetAllSelectionServerPage(ctx contractapi.TransactionContextInterface, asset string, pageSize int32, bookmark string) (internal.StoredSelectionPage, error) {
+	return stringQueryPage(ctx, selectionServerQuery(asset).String(), pageSize, bookmark)
+}
+
+// SelectNode selects the Edge Server that should run the task for the given target, using the inventory,
+// latency and resource Smart Contracts, and stores the decision in the world state
+func (s *SmartContract) SelectNode(ctx contractapi.TransactionContextInterface, target string, taskJson string) (internal.StoredSelection, error) {
+	return s.SelectNodeStrategy(ctx, target, taskJson, internal.DefaultStrategy, "")
+}
+
+// SelectNodeStrategy works like SelectNode, ranking the candidates with the strategy registered as strategyName
+func (s *SmartContract) SelectNodeStrategy(ctx contractapi.TransactionContextInterface, target string, taskJson string, strategyName string, paramsJson string) (internal.StoredSelection, error) {
+	task, err := internal.JsonToTask(taskJson)
+	if err != nil {
+		return internal.StoredSelection{}, errs.InvalidArgumentf("invalid task: %v", err)
+	}
+	strategy, err := internal.NewStrategy(strategyName, paramsJson)
+	if err != nil {
+		return internal.StoredSelection{}, err
+	}
+
+	selection, ranking, err := selectNode(ctx, target, task, strategy)
+	if err != nil {
+		return internal.StoredSelection{}, err
+	}
+
+	return selection, putSelection(ctx, selection, ranking)
+}
+
+// SelectNodePolicy works like SelectNode, evaluating the candidates under the latest version of the policy
+func (s *SmartContract) SelectNodePolicy(ctx contractapi.TransactionContextInterface, target string, taskJson string, policyID string) (internal.StoredSelection, error) {
+	task, err := internal.JsonToTask(taskJson)
+	if err != nil {
+		return internal.StoredSelection{}, errs.InvalidArgumentf("invalid task: %v", err)
+	}
+	policy, err := s.GetPolicy(ctx, policyID)
+	if err != nil {
+		return internal.StoredSelection{}, err
+	}
+
+	selection, ranking, err := selectNode(ctx, target, task, internal.NewPolicyStrategy(policy))
+	if err != nil {
+		return internal.StoredSelection{}, err
+	}
+	selection.PolicyID = policy.ID
+	selection.PolicyVersion = policy.Version
+
+	return selection, putSelection(ctx, selection, ranking)
+}
+
+// ExplainSelection returns the ranking of every candidate evaluated by the selection
+func (s *SmartContract) ExplainSelection(ctx contractapi.TransactionContextInterface, selectionID string) (internal.SelectionExplanation, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(internal.ExplanationObjectType, []string{selectionID})
+	if err != nil {
+		return internal.SelectionExplanation{}, errs.Wrap(errs.InvalidArgument, err)
+	}
+	explanationJson, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return internal.SelectionExplanation{}, errs.Internalf("failed to read from world state: %v", err)
+	}
+	if explanationJson == nil {
+		return internal.SelectionExplanation{}, errs.NotFoundf("the Explanation for selection: %s does not exist", selectionID)
+	}
+	explanation, err := internal.JsonToSelectionExplanation(string(explanationJson))
+	if err != nil {
+		return internal.SelectionExplanation{}, errs.Internalf("failed to decode the Explanation for selection: %s. %v", selectionID, err)
+	}
+	return explanation, nil
+}
+
+// selectNode evaluates every enabled server and returns the selection with the full ranking of candidates
+func selectNode(ctx contractapi.TransactionContextInterface, target string, task internal.Task, strategy internal.Strategy) (internal.StoredSelection, []internal.Candidate, error) {
+	config, err := invoke.GetConfig(ctx.GetStub())
+	if err != nil {
+		return internal.StoredSelection{}, nil, err
+	}
+	servers, err := getServerAssets(ctx, config, task.LabelSelector)
+	if err != nil {
+		return internal.StoredSelection{}, nil, err
+	}
+	latencyList, err := getAnalysisTimeTarget(ctx, config, target, task.Minutes)
+	if err != nil {
+		return internal.StoredSelection{}, nil, err
+	}
+	latencySources := make(map[string]model.LatencyAnalysis)
+	for _, latency := range latencyList {
+		latencySources[latency.Hostname] = latency
+	}
+
+	var candidates []internal.Candidate
+	for _, server := range servers {
+		if !model.IsPlaceableState(server.State) {
+			candidates = append(candidates, internal.CreateFailedCandidate(server, "state"))
+			continue
+		}
+		// NO WORK IS PLACED ON SERVERS THAT ARE CHANGING OWNER
+		if server.PendingOwner != "" {
+			candidates = append(candidates, internal.CreateFailedCandidate(server, "pendingTransfer"))
+			continue
+		}
+		if task.GPU == 1 && server.Properties.GPU != 1 {
+			candidates = append(candidates, internal.CreateFailedCandidate(server, "gpu"))
+			continue
+		}
+		hostname := internal.AssetHostname(server)
+		latency, found := latencySources[hostname]
+		if !found {
+			candidates = append(candidates, internal.CreateFailedCandidate(server, "latencyData"))
+			continue
+		}
+		// SERVERS WITHOUT RECENT RESOURCE DATA ARE NOT CONSIDERED
+		stats, err := getSummaryAnalysisTime(ctx, config, hostname, task.Minutes)
+		if err != nil {
+			return internal.StoredSelection{}, nil, err
+		}
+		if len(stats.StatSummary) == 0 {
+			candidate := internal.CreateFailedCandidate(server, "resourceData")
+			candidate.AverageLatency = latency.AverageLatency
+			candidates = append(candidates, candidate)
+			continue
+		}
+		candidates = append(candidates, internal.CreateCandidate(server, latency, stats))
+	}
+
+	ranking := internal.RankCandidates(candidates, strategy)
+	if len(ranking) == 0 || ranking[0].Rank != 1 {
+		return internal.StoredSelection{}, nil, errs.NotFoundf("no edge server is available for target: %s", target)
+	}
+
+	timestamp, err := txTimestamp(ctx)
+	if err != nil {
+		return internal.StoredSelection{}, nil, err
+	}
+	selection := internal.CreateSelection(target+"-"+ctx.GetStub().GetTxID(), target, task, strategy, timestamp, ranking[0])
+	return selection, ranking, nil
+}
+
+// putSelection stores the selection and its explanation
+func putSelection(ctx contractapi.TransactionContextInterface, selection internal.StoredSelection, ranking []internal.Candidate) error {
+	err := ctx.GetStub().PutState(selection.ID, []byte(selection.String()))
+	if err != nil {
+		return errs.Internalf("failed to put to world state. %v", err)
+	}
+
+	explanation := internal.CreateSelectionExplanation(selection, ranking)
+	key, err := ctx.GetStub().CreateCompositeKey(internal.ExplanationObjectType, []string{selection.ID})
+	if err != nil {
+		return errs.Wrap(errs.Internal, err)
+	}
+	return errs.Wrap(errs.Internal, ctx.GetStub().PutState(key, []byte(explanation.String())))
+}
+
+// GetStrategies returns the names of the strategies that can be used in SelectNodeStrategy
+func (s *SmartContract) GetStrategies(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	return internal.StrategyNames(), nil
+}
+
+// CONFIGURATION OF THE INVOKED SMART CONTRACTS
+// SetConfig stores the names of the invoked Smart Contracts and their channel, only for admin clients
+func (s *SmartContract) SetConfig(ctx contractapi.TransactionContextInterface, configJson string) (invoke.Config, error) {
+	err := access.AssertAdmin(ctx.GetClientIdentity())
+	if err != nil {
+		return invoke.Config{}, err
+	}
+	config, err := invoke.JsonToConfig(configJson)
+	if err != nil {
+		return invoke.Config{}, err
+	}
+	return invoke.PutConfig(ctx.GetStub(), config)
+}
+
+// GetConfig returns the names of the invoked Smart Contracts and their channel
+func (s *SmartContract) GetConfig(ctx contractapi.TransactionContextInterface) (invoke.Config, error) {
+	return invoke.GetConfig(ctx.GetStub())
+}
+
+// CROSS SMART CONTRACT INVOKATION
+// getServerAssets returns the active servers matching the label selector, an empty selector matches every server
+func getServerAssets(ctx contractapi.TransactionContextInterface, config invoke.Config, labelSelector string) ([]model.Asset, error) {
+	assetArray := []model.Asset{}
+	err := config.InventoryChaincode().Query(ctx.GetStub(), &assetArray, "GetAssetsByType", "server", "[]", "", labelSelector)
+	return assetArray, err
+}
+
+func getAnalysisTimeTarget(ctx contractapi.TransactionContextInterface, config invoke.Config, target string, minutes int) ([]model.LatencyAnalysis, error) {
+	analysis := []model.LatencyAnalysis{}
+	err := config.LatencyChaincode().Query(ctx.GetStub(), &analysis, "GetAnalysisTimeTarget", target, strconv.Itoa(minutes))
+	return analysis, err
+}
+
+func getSummaryAnalysisTime(ctx contractapi.TransactionContextInterface, config invoke.Config, hostname string, minutes int) (model.StatAnalysis, error) {
+	var analysis model.StatAnalysis
+	err := config.ResourcesChaincode().Query(ctx.GetStub(), &analysis, "GetSummaryAnalysisTime", hostname, strconv.Itoa(minutes))
+	return analysis, err
+}
+
+// initConfig stores the default configuration unless one was already set
+func initConfig(ctx contractapi.TransactionContextInterface) error {
+	config, err := invoke.GetConfig(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+	_, err = invoke.PutConfig(ctx.GetStub(), config)
+	return err
 }
 
 // Inernal Functions
+func txTimestamp(ctx contractapi.TransactionContextInterface) (model.Timestamp, error) {
+	now, err := clock.New(ctx.GetStub()).Now()
+	if err != nil {
+		return model.Timestamp{}, err
+	}
+	return model.CreateTimestamp(now), nil
+}
+
+// iteratorSlicer reads every selection of the iterator, newest first. Queries without results return an empty slice
 func iteratorSlicer(resultsIterator shim.StateQueryIteratorInterface) ([]internal.StoredSelection, error) {
-	var assets []internal.StoredSelection
-	if resultsIterator.HasNext() {
-		for resultsIterator.HasNext() {
-			queryResponse, err := resultsIterator.Next()
-			if err != nil {
-				return nil, err
-			}
-			asset, err := internal.JsonToStoredSelection(string(queryResponse.Value))
-			if err != nil {
-				return nil, err
-			}
-			assets = append(assets, asset)
+	assets := []internal.StoredSelection{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, errs.Internalf("failed to read query results: %v", err)
+		}
+		asset, err := internal.JsonToStoredSelection(string(queryResponse.Value))
+		if err != nil {
+			return nil, errs.Internalf("failed to decode the Asset with key: %s. %v", queryResponse.Key, err)
 		}
-	} else {
-		return nil, fmt.Errorf("failed to query chaincode. No results found for iterator")
+		assets = append(assets, asset)
 	}
 
 	sort.SliceStable(assets, func(i, j int) bool {
@@ -160,9 +428,26 @@ func stringQuery(ctx contractapi.TransactionContextInterface, queryString string
 	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
 
 	if err != nil {
-		return nil, err
+		return nil, errs.Internalf("failed to query world state: %v", err)
 	}
 	defer resultsIterator.Close()
 
 	return iteratorSlicer(resultsIterator)
 }
+
+func stringQueryPage(ctx contractapi.TransactionContextInterface, queryString string, pageSize int32, bookmark string) (internal.StoredSelectionPage, error) {
+	if err := mango.ValidatePageSize(pageSize); err != nil {
+		return internal.StoredSelectionPage{}, err
+	}
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(queryString, pageSize, bookmark)
+	if err != nil {
+		return internal.StoredSelectionPage{}, errs.Internalf("failed to query world state: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	assets, err := iteratorSlicer(resultsIterator)
+	if err != nil {
+		return internal.StoredSelectionPage{}, err
+	}
+	return internal.CreateStoredSelectionPage(assets, metadata.FetchedRecordsCount, metadata.Bookmark), nil
+}
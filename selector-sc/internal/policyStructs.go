@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+	"github.com/wI2L/jettison"
+)
+
+// -- POLICY (PDP: Policy Decision Point)
+// Versioned description of how Edge Servers are selected. Every update creates a new version,
+// previous versions are kept in the world state so selections can be audited
+type Policy struct {
+	ID          string            `json:"id"`
+	Version     int               `json:"version"`
+	Description string            `json:"description"`
+	Weights     PolicyWeights     `json:"weights"`
+	Constraints PolicyConstraints `json:"constraints"`
+	TieBreakers []string          `json:"tieBreakers"` //[latency, cpu, memory, containers]
+	UpdatedBy   string            `json:"updatedBy"`   //MSP ID of the submitting client
+	Timestamp   model.Timestamp   `json:"timestamp"`
+}
+
+type PolicyWeights struct {
+	Latency    float64 `json:"latency"`
+	CPU        float64 `json:"cpu"`
+	Memory     float64 `json:"memory"`
+	Containers float64 `json:"containers"`
+}
+
+// Hard constraints, a value of 0 means no limit
+type PolicyConstraints struct {
+	GPURequired bool    `json:"gpuRequired"`
+	MaxLatency  float64 `json:"maxLatency"`
+	MaxCPU      float64 `json:"maxCpu"`
+	MaxMemory   float64 `json:"maxMemory"`
+}
+
+const PolicyObjectType = "policy"
+
+func (d Policy) String() string {
+	s, _ := jettison.MarshalOpts(d, jettison.NilMapEmpty(), jettison.NilSliceEmpty())
+	return string(s)
+}
+
+func JsonToPolicy(v string) (policy Policy, err error) {
+	err = json.Unmarshal([]byte(v), &policy)
+	return policy, err
+}
+
+// Zero padded so the composite keys of the versions are sorted
+func PolicyVersionKey(version int) string {
+	return fmt.Sprintf("%010d", version)
+}
+
+func ValidatePolicy(policy Policy) error {
+	if policy.ID == "" {
+		return errs.InvalidArgumentf("policy was posted without ID, ignored")
+	}
+	weights := policy.Weights
+	if weights.Latency < 0 || weights.CPU < 0 || weights.Memory < 0 || weights.Containers < 0 {
+		return errs.InvalidArgumentf("the weights of policy %s can not be negative", policy.ID)
+	}
+	if weights.Latency+weights.CPU+weights.Memory+weights.Containers == 0 {
+		return errs.InvalidArgumentf("the policy %s needs at least one weight", policy.ID)
+	}
+	constraints := policy.Constraints
+	if constraints.MaxLatency < 0 || constraints.MaxCPU < 0 || constraints.MaxMemory < 0 {
+		return errs.InvalidArgumentf("the constraints of policy %s can not be negative", policy.ID)
+	}
+	if constraints.MaxCPU > 100 || constraints.MaxMemory > 100 {
+		return errs.InvalidArgumentf("the CPU and memory constraints of policy %s are percentages (0-100)", policy.ID)
+	}
+	for _, tieBreaker := range policy.TieBreakers {
+		if _, found := tieBreakerMetrics[tieBreaker]; !found {
+			return errs.InvalidArgumentf("unknown tie-breaker %s in policy %s", tieBreaker, policy.ID)
+		}
+	}
+	return nil
+}
+
+// -- POLICY STRATEGY
+// Evaluates a Policy through the Strategy interface
+type policyStrategy struct {
+	policy Policy
+}
+
+func NewPolicyStrategy(policy Policy) Strategy {
+	return policyStrategy{policy: policy}
+}
+
+func (s policyStrategy) Name() string {
+	return "policy"
+}
+
+func (s policyStrategy) Params() map[string]float64 {
+	gpuRequired := 0.0
+	if s.policy.Constraints.GPURequired {
+		gpuRequired = 1
+	}
+	return map[string]float64{
+		"latency":     s.policy.Weights.Latency,
+		"cpu":         s.policy.Weights.CPU,
+		"memory":      s.policy.Weights.Memory,
+		"containers":  s.policy.Weights.Containers,
+		"gpuRequired": gpuRequired,
+		"maxLatency":  s.policy.Constraints.MaxLatency,
+		"maxCpu":      s.policy.Constraints.MaxCPU,
+		"maxMemory":   s.policy.Constraints.MaxMemory,
+	}
+}
+
+func (s policyStrategy) Check(candidate Candidate) []string {
+	var failed []string
+	constraints := s.policy.Constraints
+	if constraints.GPURequired && candidate.Asset.Properties.GPU != 1 {
+		failed = append(failed, "gpu")
+	}
+	if constraints.MaxLatency > 0 && candidate.AverageLatency > constraints.MaxLatency {
+		failed = append(failed, "maxLatency")
+	}
+	if constraints.MaxCPU > 0 && candidate.CPUAverageUsage > constraints.MaxCPU {
+		failed = append(failed, "maxCpu")
+	}
+	if constraints.MaxMemory > 0 && candidate.MemoryUsePercentage > constraints.MaxMemory {
+		failed = append(failed, "maxMemory")
+	}
+	return failed
+}
+
+func (s policyStrategy) Score(candidate Candidate) float64 {
+	weights := s.policy.Weights
+	return weights.Latency*candidate.SubScores.Latency +
+		weights.CPU*candidate.SubScores.CPU +
+		weights.Memory*candidate.SubScores.Memory +
+		weights.Containers*candidate.SubScores.Containers
+}
+
+func (s policyStrategy) TieBreakers() []string {
+	return s.policy.TieBreakers
+}
@@ -0,0 +1,213 @@
+package internal
+
+import (
+	"encoding/json"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+)
+
+// -- SELECTION STORE
+type StoredSelection struct {
+	ID                  string             `json:"id"`
+	AssetID             string             `json:"assetID"`
+	Target              string             `json:"target"`
+	TaskID              string             `json:"taskID"`
+	Strategy            string             `json:"strategy"`
+	StrategyParams      map[string]float64 `json:"strategyParams"`
+	PolicyID            string             `json:"policyID"`
+	PolicyVersion       int                `json:"policyVersion"` //0 = not evaluated under a policy
+	Timestamp           model.Timestamp    `json:"timestamp"`
+	AverageLatency      float64            `json:"averageLatency"`
+	CPUAverageUsage     float64            `json:"cpuAverageUsage"`
+	MemoryUsePercentage float64            `json:"memoryUsePercentage"`
+	ContainersRunning   int                `json:"containersRunning"`
+	Score               float64            `json:"score"`
+}
+
+// jettison can not encode non-empty maps (StrategyParams), encoding/json is used instead
+func (d StoredSelection) String() string {
+	s, _ := json.Marshal(d)
+	return string(s)
+}
+
+func JsonToStoredSelection(v string) (selection StoredSelection, err error) {
+	err = json.Unmarshal([]byte(v), &selection)
+	return selection, err
+}
+
+func JsonToStoredSelectionArray(v string) (selection []StoredSelection, err error) {
+	err = json.Unmarshal([]byte(v), &selection)
+	return selection, err
+}
+
+// -- PAGINATION
+// Page of stored selections, the bookmark is sent back to fetch the next page
+type StoredSelectionPage struct {
+	Records      []StoredSelection `json:"records"`
+	FetchedCount int32             `json:"fetchedCount"`
+	Bookmark     string            `json:"bookmark"`
+}
+
+func CreateStoredSelectionPage(records []StoredSelection, fetchedCount int32, bookmark string) StoredSelectionPage {
+	if records == nil {
+		records = []StoredSelection{}
+	}
+	return StoredSelectionPage{Records: records, FetchedCount: fetchedCount, Bookmark: bookmark}
+}
+
+// -- TASK
+// Task describes the work that has to be placed on an Edge Server
+type Task struct {
+	ID            string `json:"id"`
+	GPU           int    `json:"gpu"`           //0 = not required, 1 = required
+	Minutes       int    `json:"minutes"`       //time window (minutes) used for the latency and resource analysis
+	LabelSelector string `json:"labelSelector"` //scopes the candidate servers, e.g. "zone=factory-2,tier in (gpu,npu)"
+}
+
+const DefaultAnalysisMinutes = 5
+
+func JsonToTask(v string) (task Task, err error) {
+	if v == "" {
+		v = "{}"
+	}
+	err = json.Unmarshal([]byte(v), &task)
+	if task.Minutes <= 0 {
+		task.Minutes = DefaultAnalysisMinutes
+	}
+	return task, err
+}
+
+// -- CANDIDATE
+// Edge Server considered to run the task, with the metrics used to rank it
+type Candidate struct {
+	Asset               model.Asset `json:"asset"`
+	AverageLatency      float64     `json:"averageLatency"`
+	CPUAverageUsage     float64     `json:"cpuAverageUsage"`
+	MemoryUsePercentage float64     `json:"memoryUsePercentage"`
+	ContainersRunning   int         `json:"containersRunning"`
+	SubScores           SubScores   `json:"subScores"`
+	Score               float64     `json:"score"`
+	Rank                int         `json:"rank"`              //1 = selected, 0 = not eligible
+	FailedConstraints   []string    `json:"failedConstraints"` //[state, pendingTransfer, latencyData, resourceData, gpu, ...strategy constraints]
+}
+
+// jettison can not encode non-empty maps (Asset.Labels), encoding/json is used instead
+func (d Candidate) String() string {
+	s, _ := json.Marshal(d)
+	return string(s)
+}
+
+func CreateCandidate(asset model.Asset, latency model.LatencyAnalysis, stats model.StatAnalysis) Candidate {
+	return Candidate{
+		Asset:               asset,
+		AverageLatency:      latency.AverageLatency,
+		CPUAverageUsage:     stats.CPUAverageUsage,
+		MemoryUsePercentage: stats.MemoryUsePercentage,
+		ContainersRunning:   stats.ContainersRunning,
+	}
+}
+
+// Candidate that could not be evaluated, e.g. missing latency or resource data
+func CreateFailedCandidate(asset model.Asset, failedConstraint string) Candidate {
+	return Candidate{
+		Asset:             asset,
+		FailedConstraints: []string{failedConstraint},
+	}
+}
+
+// Hostname used by the collectors to identify the asset, falls back to the asset ID
+func AssetHostname(asset model.Asset) string {
+	return asset.CollectorHostname()
+}
+
+func CreateSelection(id string, target string, task Task, strategy Strategy, timestamp model.Timestamp, candidate Candidate) StoredSelection {
+	return StoredSelection{
+		ID:                  id,
+		AssetID:             candidate.Asset.ID,
+		Target:              target,
+		TaskID:              task.ID,
+		Strategy:            strategy.Name(),
+		StrategyParams:      strategy.Params(),
+		Timestamp:           timestamp,
+		AverageLatency:      candidate.AverageLatency,
+		CPUAverageUsage:     candidate.CPUAverageUsage,
+		MemoryUsePercentage: candidate.MemoryUsePercentage,
+		ContainersRunning:   candidate.ContainersRunning,
+		Score:               candidate.Score,
+	}
+}
+
+// -- SELECTION EXPLANATION
+// Companion record of a StoredSelection with the ranking of every candidate
+type SelectionExplanation struct {
+	SelectionID    string             `json:"selectionID"`
+	Target         string             `json:"target"`
+	TaskID         string             `json:"taskID"`
+	Strategy       string             `json:"strategy"`
+	StrategyParams map[string]float64 `json:"strategyParams"`
+	PolicyID       string             `json:"policyID"`
+	PolicyVersion  int                `json:"policyVersion"`
+	Timestamp      model.Timestamp    `json:"timestamp"`
+	Ranking        []RankedCandidate  `json:"ranking"`
+}
+
+// Candidate without the inventory properties, so host details are not copied into the explanation
+type RankedCandidate struct {
+	Rank                int       `json:"rank"` //0 = not eligible
+	AssetID             string    `json:"assetID"`
+	Hostname            string    `json:"hostname"`
+	AverageLatency      float64   `json:"averageLatency"`
+	CPUAverageUsage     float64   `json:"cpuAverageUsage"`
+	MemoryUsePercentage float64   `json:"memoryUsePercentage"`
+	ContainersRunning   int       `json:"containersRunning"`
+	SubScores           SubScores `json:"subScores"`
+	FailedConstraints   []string  `json:"failedConstraints"`
+	Score               float64   `json:"score"`
+}
+
+const ExplanationObjectType = "explanation"
+
+// jettison can not encode non-empty maps (StrategyParams), encoding/json is used instead
+func (d SelectionExplanation) String() string {
+	s, _ := json.Marshal(d)
+	return string(s)
+}
+
+func JsonToSelectionExplanation(v string) (explanation SelectionExplanation, err error) {
+	err = json.Unmarshal([]byte(v), &explanation)
+	return explanation, err
+}
+
+func CreateSelectionExplanation(selection StoredSelection, candidates []Candidate) SelectionExplanation {
+	ranking := make([]RankedCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		failed := c.FailedConstraints
+		if failed == nil {
+			failed = []string{}
+		}
+		ranking = append(ranking, RankedCandidate{
+			Rank:                c.Rank,
+			AssetID:             c.Asset.ID,
+			Hostname:            AssetHostname(c.Asset),
+			AverageLatency:      c.AverageLatency,
+			CPUAverageUsage:     c.CPUAverageUsage,
+			MemoryUsePercentage: c.MemoryUsePercentage,
+			ContainersRunning:   c.ContainersRunning,
+			SubScores:           c.SubScores,
+			FailedConstraints:   failed,
+			Score:               c.Score,
+		})
+	}
+
+	return SelectionExplanation{
+		SelectionID:    selection.ID,
+		Target:         selection.Target,
+		TaskID:         selection.TaskID,
+		Strategy:       selection.Strategy,
+		StrategyParams: selection.StrategyParams,
+		PolicyID:       selection.PolicyID,
+		PolicyVersion:  selection.PolicyVersion,
+		Timestamp:      selection.Timestamp,
+		Ranking:        ranking,
+	}
+}
@@ -0,0 +1,298 @@
+package internal
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/errs"
+)
+
+// -- SUB SCORES
+// Metrics of a candidate normalized between the best (0) and the worst (1) candidate of the selection
+type SubScores struct {
+	Latency    float64 `json:"latency"`
+	CPU        float64 `json:"cpu"`
+	Memory     float64 `json:"memory"`
+	Containers float64 `json:"containers"`
+}
+
+// -- STRATEGY
+// Strategy decides which candidates can run a task and how they are scored, lower scores are better
+type Strategy interface {
+	Name() string
+	Params() map[string]float64
+	// Check returns the constraints failed by the candidate, candidates failing any constraint are never selected
+	Check(candidate Candidate) []string
+	Score(candidate Candidate) float64
+}
+
+// StrategyFactory creates a Strategy from the parameters sent in the transaction
+type StrategyFactory func(params map[string]float64) (Strategy, error)
+
+const DefaultStrategy = "latency-first"
+
+var strategyRegistry = map[string]StrategyFactory{
+	"latency-first": newSingleMetricStrategy("latency-first", func(s SubScores) float64 { return s.Latency }),
+	"least-cpu":     newSingleMetricStrategy("least-cpu", func(s SubScores) float64 { return s.CPU }),
+	"least-memory":  newSingleMetricStrategy("least-memory", func(s SubScores) float64 { return s.Memory }),
+	"weighted-sum":  newWeightedSumStrategy,
+	"gpu-required":  newGPURequiredStrategy,
+}
+
+// RegisterStrategy adds (or replaces) a strategy that can be selected by name
+func RegisterStrategy(name string, factory StrategyFactory) {
+	strategyRegistry[name] = factory
+}
+
+func StrategyNames() []string {
+	names := make([]string, 0, len(strategyRegistry))
+	for name := range strategyRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func NewStrategy(name string, paramsJson string) (Strategy, error) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+	factory, found := strategyRegistry[name]
+	if !found {
+		return nil, errs.InvalidArgumentf("the strategy %s does not exist", name)
+	}
+
+	params := make(map[string]float64)
+	if paramsJson != "" {
+		err := json.Unmarshal([]byte(paramsJson), &params)
+		if err != nil {
+			return nil, errs.InvalidArgumentf("invalid parameters for strategy %s: %v", name, err)
+		}
+	}
+	return factory(params)
+}
+
+// TieBreaker can be implemented by strategies that decide the order of candidates with the same score
+type TieBreaker interface {
+	TieBreakers() []string
+}
+
+var DefaultTieBreakers = []string{"latency", "cpu", "memory"}
+
+var tieBreakerMetrics = map[string]func(Candidate) float64{
+	"latency":    func(c Candidate) float64 { return c.AverageLatency },
+	"cpu":        func(c Candidate) float64 { return c.CPUAverageUsage },
+	"memory":     func(c Candidate) float64 { return c.MemoryUsePercentage },
+	"containers": func(c Candidate) float64 { return float64(c.ContainersRunning) },
+}
+
+// RankCandidates checks the strategy constraints and sorts the candidates from best to worst.
+// Ties are broken by the strategy tie-breakers (latency, CPU usage and memory usage by default) and finally by ID,
+// so that every endorsing peer reaches the same order. Candidates failing any constraint are returned last with Rank 0
+func RankCandidates(candidates []Candidate, strategy Strategy) []Candidate {
+	var eligible, failed []Candidate
+	for _, candidate := range candidates {
+		if len(candidate.FailedConstraints) == 0 {
+			candidate.FailedConstraints = strategy.Check(candidate)
+		}
+		if len(candidate.FailedConstraints) == 0 {
+			eligible = append(eligible, candidate)
+		} else {
+			failed = append(failed, candidate)
+		}
+	}
+
+	eligible = NormalizeCandidates(eligible)
+	for i := range eligible {
+		eligible[i].Score = strategy.Score(eligible[i])
+	}
+
+	tieBreakers := DefaultTieBreakers
+	if t, ok := strategy.(TieBreaker); ok && len(t.TieBreakers()) > 0 {
+		tieBreakers = t.TieBreakers()
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		if eligible[i].Score != eligible[j].Score {
+			return eligible[i].Score < eligible[j].Score
+		}
+		return candidateLess(eligible[i], eligible[j], tieBreakers)
+	})
+	for i := range eligible {
+		eligible[i].Rank = i + 1
+	}
+	sort.SliceStable(failed, func(i, j int) bool {
+		return failed[i].Asset.ID < failed[j].Asset.ID
+	})
+
+	return append(eligible, failed...)
+}
+
+func candidateLess(a Candidate, b Candidate, tieBreakers []string) bool {
+	for _, tieBreaker := range tieBreakers {
+		metric := tieBreakerMetrics[tieBreaker]
+		if metric(a) != metric(b) {
+			return metric(a) < metric(b)
+		}
+	}
+	return a.Asset.ID < b.Asset.ID
+}
+
+// NormalizeCandidates fills the SubScores of every candidate using min-max normalization
+func NormalizeCandidates(candidates []Candidate) []Candidate {
+	if len(candidates) == 0 {
+		return candidates
+	}
+	minimum, maximum := candidates[0], candidates[0]
+	for _, c := range candidates {
+		if c.AverageLatency < minimum.AverageLatency {
+			minimum.AverageLatency = c.AverageLatency
+		}
+		if c.AverageLatency > maximum.AverageLatency {
+			maximum.AverageLatency = c.AverageLatency
+		}
+		if c.CPUAverageUsage < minimum.CPUAverageUsage {
+			minimum.CPUAverageUsage = c.CPUAverageUsage
+		}
+		if c.CPUAverageUsage > maximum.CPUAverageUsage {
+			maximum.CPUAverageUsage = c.CPUAverageUsage
+		}
+		if c.MemoryUsePercentage < minimum.MemoryUsePercentage {
+			minimum.MemoryUsePercentage = c.MemoryUsePercentage
+		}
+		if c.MemoryUsePercentage > maximum.MemoryUsePercentage {
+			maximum.MemoryUsePercentage = c.MemoryUsePercentage
+		}
+		if c.ContainersRunning < minimum.ContainersRunning {
+			minimum.ContainersRunning = c.ContainersRunning
+		}
+		if c.ContainersRunning > maximum.ContainersRunning {
+			maximum.ContainersRunning = c.ContainersRunning
+		}
+	}
+
+	for i, c := range candidates {
+		candidates[i].SubScores = SubScores{
+			Latency:    normalize(c.AverageLatency, minimum.AverageLatency, maximum.AverageLatency),
+			CPU:        normalize(c.CPUAverageUsage, minimum.CPUAverageUsage, maximum.CPUAverageUsage),
+			Memory:     normalize(c.MemoryUsePercentage, minimum.MemoryUsePercentage, maximum.MemoryUsePercentage),
+			Containers: normalize(float64(c.ContainersRunning), float64(minimum.ContainersRunning), float64(maximum.ContainersRunning)),
+		}
+	}
+	return candidates
+}
+
+func normalize(v float64, minimum float64, maximum float64) float64 {
+	if maximum == minimum {
+		return 0
+	}
+	return (v - minimum) / (maximum - minimum)
+}
+
+// -- SINGLE METRIC (latency-first, least-cpu, least-memory)
+type singleMetricStrategy struct {
+	name   string
+	metric func(SubScores) float64
+}
+
+func newSingleMetricStrategy(name string, metric func(SubScores) float64) StrategyFactory {
+	return func(params map[string]float64) (Strategy, error) {
+		if len(params) > 0 {
+			return nil, errs.InvalidArgumentf("the strategy %s does not accept parameters", name)
+		}
+		return singleMetricStrategy{name: name, metric: metric}, nil
+	}
+}
+
+func (s singleMetricStrategy) Name() string {
+	return s.name
+}
+
+func (s singleMetricStrategy) Params() map[string]float64 {
+	return map[string]float64{}
+}
+
+func (s singleMetricStrategy) Check(candidate Candidate) []string {
+	return nil
+}
+
+func (s singleMetricStrategy) Score(candidate Candidate) float64 {
+	return s.metric(candidate.SubScores)
+}
+
+// -- WEIGHTED SUM
+type weightedSumStrategy struct {
+	weights map[string]float64
+}
+
+var defaultWeights = map[string]float64{
+	"latency":    0.4,
+	"cpu":        0.3,
+	"memory":     0.2,
+	"containers": 0.1,
+}
+
+func newWeightedSumStrategy(params map[string]float64) (Strategy, error) {
+	weights := make(map[string]float64)
+	for k, v := range defaultWeights {
+		weights[k] = v
+	}
+	for k, v := range params {
+		if _, found := defaultWeights[k]; !found {
+			return nil, errs.InvalidArgumentf("unknown weight %s for strategy weighted-sum", k)
+		}
+		if v < 0 {
+			return nil, errs.InvalidArgumentf("the weight %s for strategy weighted-sum can not be negative", k)
+		}
+		weights[k] = v
+	}
+	return weightedSumStrategy{weights: weights}, nil
+}
+
+func (s weightedSumStrategy) Name() string {
+	return "weighted-sum"
+}
+
+func (s weightedSumStrategy) Params() map[string]float64 {
+	return s.weights
+}
+
+func (s weightedSumStrategy) Check(candidate Candidate) []string {
+	return nil
+}
+
+func (s weightedSumStrategy) Score(candidate Candidate) float64 {
+	return s.weights["latency"]*candidate.SubScores.Latency +
+		s.weights["cpu"]*candidate.SubScores.CPU +
+		s.weights["memory"]*candidate.SubScores.Memory +
+		s.weights["containers"]*candidate.SubScores.Containers
+}
+
+// -- GPU REQUIRED
+// Only servers with a GPU are accepted, ranked by latency
+type gpuRequiredStrategy struct{}
+
+func newGPURequiredStrategy(params map[string]float64) (Strategy, error) {
+	if len(params) > 0 {
+		return nil, errs.InvalidArgumentf("the strategy gpu-required does not accept parameters")
+	}
+	return gpuRequiredStrategy{}, nil
+}
+
+func (s gpuRequiredStrategy) Name() string {
+	return "gpu-required"
+}
+
+func (s gpuRequiredStrategy) Params() map[string]float64 {
+	return map[string]float64{}
+}
+
+func (s gpuRequiredStrategy) Check(candidate Candidate) []string {
+	if candidate.Asset.Properties.GPU != 1 {
+		return []string{"gpu"}
+	}
+	return nil
+}
+
+func (s gpuRequiredStrategy) Score(candidate Candidate) float64 {
+	return candidate.SubScores.Latency
+}
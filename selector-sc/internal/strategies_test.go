@@ -0,0 +1,202 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dmonteroh/distributed-resources-smartcontract/common/model"
+)
+
+func candidate(id string, latency float64, cpu float64, memory float64) Candidate {
+	return Candidate{
+		Asset:               model.Asset{ID: id},
+		AverageLatency:      latency,
+		CPUAverageUsage:     cpu,
+		MemoryUsePercentage: memory,
+	}
+}
+
+func rankedIDs(candidates []Candidate) []string {
+	ids := []string{}
+	for _, c := range candidates {
+		ids = append(ids, c.Asset.ID)
+	}
+	return ids
+}
+
+func mustStrategy(t *testing.T, name string, paramsJson string) Strategy {
+	t.Helper()
+	strategy, err := NewStrategy(name, paramsJson)
+	if err != nil {
+		t.Fatalf("NewStrategy(%s): %v", name, err)
+	}
+	return strategy
+}
+
+func TestRankCandidatesTieBreaking(t *testing.T) {
+	tests := []struct {
+		name       string
+		strategy   Strategy
+		candidates []Candidate
+		want       []string
+	}{
+		{
+			name:     "equal scores are ordered by latency",
+			strategy: mustStrategy(t, "least-cpu", ""),
+			candidates: []Candidate{
+				candidate("a", 30, 50, 10),
+				candidate("b", 10, 50, 10),
+				candidate("c", 20, 50, 10),
+			},
+			want: []string{"b", "c", "a"},
+		},
+		{
+			name:     "equal latency is ordered by cpu then memory",
+			strategy: mustStrategy(t, "least-memory", ""),
+			candidates: []Candidate{
+				candidate("a", 10, 40, 20),
+				candidate("b", 10, 30, 20),
+				candidate("c", 10, 30, 20),
+			},
+			want: []string{"b", "c", "a"},
+		},
+		{
+			name:     "identical metrics are ordered by ID",
+			strategy: mustStrategy(t, "latency-first", ""),
+			candidates: []Candidate{
+				candidate("s3", 10, 10, 10),
+				candidate("s1", 10, 10, 10),
+				candidate("s2", 10, 10, 10),
+			},
+			want: []string{"s1", "s2", "s3"},
+		},
+		{
+			name: "policy tie-breakers replace the defaults",
+			strategy: NewPolicyStrategy(Policy{
+				ID:          "p",
+				Weights:     PolicyWeights{Containers: 1},
+				TieBreakers: []string{"memory"},
+			}),
+			candidates: []Candidate{
+				candidate("a", 10, 10, 30),
+				candidate("b", 20, 20, 10),
+				candidate("c", 30, 30, 20),
+			},
+			want: []string{"b", "c", "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// THE INPUT ORDER MUST NOT CHANGE THE RANKING
+			reversed := make([]Candidate, len(tt.candidates))
+			for i, c := range tt.candidates {
+				reversed[len(tt.candidates)-1-i] = c
+			}
+			for _, input := range [][]Candidate{tt.candidates, reversed} {
+				ranked := RankCandidates(append([]Candidate{}, input...), tt.strategy)
+				if got := rankedIDs(ranked); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ranking = %v, want %v", got, tt.want)
+				}
+				for i, c := range ranked {
+					if c.Rank != i+1 {
+						t.Errorf("%s has rank %d, want %d", c.Asset.ID, c.Rank, i+1)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeCandidatesEqualValues(t *testing.T) {
+	candidates := []Candidate{
+		candidate("a", 25, 50, 75),
+		candidate("b", 25, 50, 75),
+		candidate("c", 25, 50, 75),
+	}
+	for _, c := range NormalizeCandidates(candidates) {
+		if c.SubScores != (SubScores{}) {
+			t.Errorf("%s has sub-scores %+v, equal values must normalize to 0", c.Asset.ID, c.SubScores)
+		}
+	}
+}
+
+func TestNormalizeCandidatesMinMax(t *testing.T) {
+	candidates := NormalizeCandidates([]Candidate{
+		candidate("a", 10, 50, 20),
+		candidate("b", 20, 50, 60),
+		candidate("c", 30, 50, 40),
+	})
+	want := []SubScores{
+		{Latency: 0, CPU: 0, Memory: 0},
+		{Latency: 0.5, CPU: 0, Memory: 1},
+		{Latency: 1, CPU: 0, Memory: 0.5},
+	}
+	for i, c := range candidates {
+		if c.SubScores != want[i] {
+			t.Errorf("%s has sub-scores %+v, want %+v", c.Asset.ID, c.SubScores, want[i])
+		}
+	}
+}
+
+func TestNormalizeCandidatesEmpty(t *testing.T) {
+	if got := NormalizeCandidates(nil); len(got) != 0 {
+		t.Errorf("NormalizeCandidates(nil) = %v, want no candidates", got)
+	}
+}
+
+func TestRankCandidatesConstraintFailures(t *testing.T) {
+	strategy := NewPolicyStrategy(Policy{
+		ID:      "p",
+		Weights: PolicyWeights{Latency: 1},
+		Constraints: PolicyConstraints{
+			GPURequired: true,
+			MaxLatency:  50,
+			MaxCPU:      80,
+			MaxMemory:   80,
+		},
+	})
+	gpu := func(c Candidate) Candidate {
+		c.Asset.Properties.GPU = 1
+		return c
+	}
+
+	ranked := RankCandidates([]Candidate{
+		candidate("z-all", 90, 90, 90),
+		gpu(candidate("ok-slow", 40, 10, 10)),
+		CreateFailedCandidate(model.Asset{ID: "m-missing"}, "latencyData"),
+		gpu(candidate("c-cpu-memory", 10, 90, 90)),
+		gpu(candidate("ok-fast", 20, 10, 10)),
+	}, strategy)
+
+	want := []struct {
+		id     string
+		rank   int
+		failed []string
+	}{
+		{"ok-fast", 1, nil},
+		{"ok-slow", 2, nil},
+		// FAILED CANDIDATES COME LAST, ORDERED BY ID, WITH THE CONSTRAINTS IN THE ORDER THEY ARE CHECKED
+		{"c-cpu-memory", 0, []string{"maxCpu", "maxMemory"}},
+		{"m-missing", 0, []string{"latencyData"}},
+		{"z-all", 0, []string{"gpu", "maxLatency", "maxCpu", "maxMemory"}},
+	}
+	if len(ranked) != len(want) {
+		t.Fatalf("got %d candidates, want %d", len(ranked), len(want))
+	}
+	for i, w := range want {
+		c := ranked[i]
+		if c.Asset.ID != w.id || c.Rank != w.rank || !reflect.DeepEqual(c.FailedConstraints, w.failed) {
+			t.Errorf("position %d = {%s rank %d failed %v}, want {%s rank %d failed %v}",
+				i, c.Asset.ID, c.Rank, c.FailedConstraints, w.id, w.rank, w.failed)
+		}
+	}
+}
+
+func TestRankCandidatesKeepsDataFailures(t *testing.T) {
+	// A CANDIDATE WITHOUT DATA IS NOT RE-CHECKED, ITS ZERO METRICS WOULD PASS THE CONSTRAINTS
+	ranked := RankCandidates([]Candidate{CreateFailedCandidate(model.Asset{ID: "a"}, "resourceData")}, mustStrategy(t, "gpu-required", ""))
+	if got := ranked[0].FailedConstraints; !reflect.DeepEqual(got, []string{"resourceData"}) {
+		t.Errorf("failed constraints = %v, want [resourceData]", got)
+	}
+}